@@ -1,16 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path"
 	"reflect"
 )
 
-// An interface to an executable command
+// An interface to an executable command. Exec
+// stops what it's doing when ctx is canceled.
 type Cmd interface {
 	SetWd(directory string)
-	Exec(args []string) error
+	Exec(ctx context.Context, args []string) error
 	Summary() string
 }
 
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/ghthor/gospec"
 	. "github.com/ghthor/gospec"
 
@@ -12,7 +14,7 @@ type C struct{}
 
 func (c *C) SetWd(string) {}
 
-func (c *C) Exec(args []string) error {
+func (c *C) Exec(ctx context.Context, args []string) error {
 	return nil
 }
 
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// The cause of the context returned by InterruptContext being canceled
+var ErrInterrupted = errors.New("interrupted")
+
+// Returns a context that is canceled with ErrInterrupted when the
+// process is interrupted, so a command can stop the git process it's
+// waiting on and roll back what it has done. The interrupt is only
+// caught once, interrupting again terminates the process.
+func InterruptContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-interrupts:
+			cancel(ErrInterrupted)
+		case <-ctx.Done():
+		}
+		signal.Stop(interrupts)
+	}()
+
+	return ctx, func() { cancel(context.Canceled) }
+}
@@ -1,12 +1,14 @@
 package test_cmd
 
+import "context"
+
 type Cmd struct {
 	wasExecuted bool
 }
 
 func (c *Cmd) SetWd(string) {}
 
-func (c *Cmd) Exec([]string) error {
+func (c *Cmd) Exec(context.Context, []string) error {
 	c.wasExecuted = true
 	return nil
 }
@@ -0,0 +1,161 @@
+package agenda
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ghthor/journal/config"
+	"github.com/ghthor/journal/idea"
+)
+
+var Cmd = NewCmd(nil)
+
+type cmd struct {
+	Now func() time.Time
+
+	flagSet *flag.FlagSet
+
+	wd string // working directory
+
+	days int
+
+	// Output for the agenda
+	Stdout io.Writer
+}
+
+func NewCmd(flagSet *flag.FlagSet) *cmd {
+	if flagSet == nil {
+		flagSet = flag.NewFlagSet("agenda", flag.ExitOnError)
+	}
+
+	c := &cmd{
+		flagSet: flagSet,
+		Stdout:  os.Stdout,
+	}
+
+	c.flagSet.IntVar(&c.days, "days", 7, "list the ideas and tasks due within this many days")
+
+	return c
+}
+
+func (c *cmd) SetWd(directory string) {
+	c.wd = directory
+}
+
+// An idea or a task that is due
+type item struct {
+	due  time.Time
+	idea idea.Idea
+
+	// Empty if the item is the idea
+	task string
+}
+
+type byDueDate []item
+
+func (items byDueDate) Len() int      { return len(items) }
+func (items byDueDate) Swap(i, j int) { items[i], items[j] = items[j], items[i] }
+func (items byDueDate) Less(i, j int) bool {
+	if items[i].due.Equal(items[j].due) {
+		return items[i].idea.Id < items[j].idea.Id
+	}
+	return items[i].due.Before(items[j].due)
+}
+
+func (c *cmd) Exec(ctx context.Context, args []string) error {
+	c.flagSet.Parse(args)
+
+	a := c.flagSet.Args()
+
+	var path string
+
+	switch len(a) {
+	case 0:
+		path = c.wd
+	case 1:
+		path = a[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.wd, path)
+		}
+
+	default:
+		return errors.New("too many arguments")
+	}
+
+	if c.days < 0 {
+		return errors.New("the number of days cannot be negative")
+	}
+
+	// Set default time provider
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	now := c.Now()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := cfg.IdeaStore(path)
+	if err != nil {
+		return err
+	}
+
+	ideas, err := store.ActiveIdeas()
+	if err != nil {
+		return err
+	}
+
+	items := make([]item, 0, len(ideas))
+
+	for _, i := range ideas {
+		schedule, err := i.Schedule()
+		if err != nil {
+			return fmt.Errorf("idea %d: %v", i.Id, err)
+		}
+
+		if idea.IsDueWithin(schedule.Due, now, c.days) {
+			items = append(items, item{schedule.Due, i, ""})
+		}
+
+		for _, task := range schedule.Tasks {
+			if !task.Done && idea.IsDueWithin(task.Due, now, c.days) {
+				items = append(items, item{task.Due, i, task.Text})
+			}
+		}
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintf(c.Stdout, "nothing is due in the next %d days\n", c.days)
+		return nil
+	}
+
+	sort.Stable(byDueDate(items))
+
+	for _, item := range items {
+		line := fmt.Sprintf("%s  [%d] %s", item.due.Format(idea.DateLayout), item.idea.Id, item.idea.Name)
+		if item.task != "" {
+			line += ": " + item.task
+		}
+
+		if (idea.Schedule{Due: item.due}).IsOverdue(now) {
+			line += " (overdue)"
+		}
+
+		fmt.Fprintln(c.Stdout, line)
+	}
+
+	return nil
+}
+
+func (c cmd) Summary() string {
+	return "list the ideas and tasks that are due soon"
+}
@@ -0,0 +1,95 @@
+package agenda
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/idea"
+	initialize "github.com/ghthor/journal/init"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeAgendaCmd(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("the `agenda` command", func() {
+		// Create a temporary journal
+		journalDir, err := ioutil.TempDir("", "agenda_cmd_desc_")
+		c.Assume(err, IsNil)
+		defer func() {
+			c.Assume(os.RemoveAll(journalDir), IsNil)
+		}()
+
+		commitable, err := initialize.Journal(ctx, journalDir)
+		c.Assume(err, IsNil)
+		c.Assume(git.Commit(ctx, commitable), IsNil)
+
+		store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+		c.Assume(err, IsNil)
+
+		saveIdea := func(i idea.Idea) {
+			commitable, err := store.SaveIdea(ctx, &i)
+			c.Assume(err, IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
+		}
+
+		output := bytes.NewBuffer(nil)
+
+		cmd := NewCmd(nil)
+		cmd.SetWd(journalDir)
+		cmd.Stdout = output
+		cmd.Now = func() time.Time {
+			return time.Date(2015, 1, 3, 12, 0, 0, 0, time.UTC)
+		}
+
+		c.Specify("will list the ideas and tasks that are due sorted by date", func() {
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "due later",
+				Body:   "Due: 2015-01-08\n\n- [ ] a task due:2015-01-04\n- [x] a completed task due:2015-01-04\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "overdue",
+				Body:   "Due: 2015-01-01\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "due after the agenda",
+				Body:   "Due: 2015-01-20\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Inactive,
+				Name:   "inactive",
+				Body:   "Due: 2015-01-04\n",
+			})
+
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
+			c.Expect(output.String(), Equals, `2015-01-01  [2] overdue (overdue)
+2015-01-04  [1] due later: a task
+2015-01-08  [1] due later
+`)
+
+			c.Specify("within a number of days", func() {
+				output.Reset()
+
+				c.Assume(cmd.Exec(ctx, []string{"-days", "1"}), IsNil)
+				c.Expect(output.String(), Equals, `2015-01-01  [2] overdue (overdue)
+2015-01-04  [1] due later: a task
+`)
+			})
+		})
+
+		c.Specify("will report when nothing is due", func() {
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
+			c.Expect(output.String(), Equals, "nothing is due in the next 7 days\n")
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package agenda
+
+import (
+	"github.com/ghthor/gospec"
+	"testing"
+)
+
+func TestUnitSpecs(t *testing.T) {
+	r := gospec.NewRunner()
+
+	r.AddSpec(DescribeAgendaCmd)
+
+	gospec.MainGoTest(r, t)
+}
@@ -1,8 +1,12 @@
 package fix
 
 import (
+	"context"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 
 	"github.com/ghthor/journal/fix"
@@ -16,6 +20,12 @@ type cmd struct {
 	wd string // working directory
 
 	noCommit bool
+
+	// Fix a clone of the journal and print the changes instead
+	dryRun *bool
+
+	// Output for reporting likely duplicate ideas and the dry run
+	Stdout io.Writer
 }
 
 func NewCmd(flagSet *flag.FlagSet) *cmd {
@@ -25,9 +35,11 @@ func NewCmd(flagSet *flag.FlagSet) *cmd {
 
 	c := &cmd{
 		flagSet: flagSet,
+		Stdout:  os.Stdout,
 	}
 
 	//c.flagSet.BoolVar(&c.noCommit, "no-commit", false, "don't commit the modifications made by fix to the repository")
+	c.dryRun = c.flagSet.Bool("dry-run", false, "fix a temporary clone of the journal and print the commits and changes it would make")
 
 	return c
 }
@@ -36,7 +48,7 @@ func (c *cmd) SetWd(directory string) {
 	c.wd = directory
 }
 
-func (c *cmd) Exec(args []string) error {
+func (c *cmd) Exec(ctx context.Context, args []string) error {
 	c.flagSet.Parse(args)
 
 	a := c.flagSet.Args()
@@ -56,8 +68,18 @@ func (c *cmd) Exec(args []string) error {
 		return errors.New("too many arguments")
 	}
 
+	if *c.dryRun {
+		plan, err := fix.DryRun(ctx, path, fix.Options{Report: c.Stdout})
+		if err != nil {
+			return err
+		}
+
+		c.printPlan(plan)
+		return nil
+	}
+
 	// FIX
-	_, err := fix.Fix(path)
+	_, err := fix.FixWith(ctx, path, fix.Options{Report: c.Stdout})
 	if err != nil {
 		return err
 	}
@@ -65,6 +87,22 @@ func (c *cmd) Exec(args []string) error {
 	return nil
 }
 
+func (c *cmd) printPlan(plan fix.Plan) {
+	if len(plan.Commits) == 0 {
+		fmt.Fprintln(c.Stdout, "the journal doesn't need to be fixed")
+		return
+	}
+
+	fmt.Fprintf(c.Stdout, "fixing the journal would make %d commits\n", len(plan.Commits))
+	for i, commit := range plan.Commits {
+		fmt.Fprintf(c.Stdout, "%3d %s\n", i+1, commit.Subject())
+	}
+
+	if len(plan.Diff) != 0 {
+		fmt.Fprint(c.Stdout, "\n"+plan.Diff)
+	}
+}
+
 func (c cmd) Summary() string {
 	return "upgrade the storage format"
 }
@@ -1,15 +1,19 @@
 package fix_test
 
 import (
+	"bytes"
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ghthor/gospec"
 	. "github.com/ghthor/gospec"
 
 	"github.com/ghthor/journal/cmd"
 	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/lock"
 
 	"github.com/ghthor/journal/cmd_verbs/fix"
 	fixPkg "github.com/ghthor/journal/fix"
@@ -17,6 +21,8 @@ import (
 )
 
 func DescribeFixCmd(c gospec.Context) {
+	ctx := context.Background()
+
 	var tmpDir = func() (directory string, cleanUp func()) {
 		directory, err := ioutil.TempDir("", "journal-cmd_verbs-fix")
 		c.Assume(err, IsNil)
@@ -34,13 +40,13 @@ func DescribeFixCmd(c gospec.Context) {
 		c.Assume(needsFixed, IsTrue)
 
 		c.Specify("and commit the modifications to git", func() {
-			c.Expect(cmd.Exec(args), IsNil)
+			c.Expect(cmd.Exec(ctx, args), IsNil)
 
 			needsFixed, err := fixPkg.NeedsFixed(journalDir)
 			c.Assume(err, IsNil)
 			c.Expect(needsFixed, IsFalse)
 
-			c.Expect(git.IsClean(journalDir), IsNil)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
 			// TODO compare commits
 		})
 
@@ -89,10 +95,53 @@ func DescribeFixCmd(c gospec.Context) {
 
 		})
 
+		c.Specify("will preview fixing a journal without changing it", func() {
+			journalDir, _, err := case_0_static.NewIn(d)
+			c.Assume(err, IsNil)
+
+			head, err := git.Command(journalDir, "rev-parse", "HEAD").Output()
+			c.Assume(err, IsNil)
+
+			out := bytes.NewBuffer(nil)
+			cmd := fix.NewCmd(nil)
+			cmd.Stdout = out
+			c.Assume(cmd.Exec(ctx, []string{"-dry-run", journalDir}), IsNil)
+
+			output := out.String()
+			c.Expect(strings.HasPrefix(output, `fixing the journal would make 17 commits
+  1 journal - fix - begin
+  2 journal - fix - moved all entries to entry/
+`), IsTrue)
+			c.Expect(strings.Contains(output, " 17 journal - fix - format version 1\n\ndiff --git "), IsTrue)
+			c.Expect(strings.Contains(output, `--- a/2014-01-03-0000-EST
++++ b/entry/2014-01-03-0000-EST
+@@ -1,7 +1,6 @@
+ Fri Jan  3 00:00:00 EST 2014
+ 
+-#~ Commit Msg
+-# Entry 3
++# Commit Msg | Entry 3
+`), IsTrue)
+			c.Expect(strings.Contains(output, "+++ b/idea/1\n"), IsTrue)
+
+			// The journal hasn't been touched
+			after, err := git.Command(journalDir, "rev-parse", "HEAD").Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(after), Equals, string(head))
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+			_, err = os.Stat(filepath.Join(journalDir, ".git", lock.Filename))
+			c.Expect(os.IsNotExist(err), IsTrue)
+
+			needsFixed, err := fixPkg.NeedsFixed(journalDir)
+			c.Assume(err, IsNil)
+			c.Expect(needsFixed, IsTrue)
+		})
+
 		c.Specify("will error with too many arguments", func() {
 			cmd := fix.NewCmd(nil)
 
-			err := cmd.Exec([]string{d, "another/argument"})
+			err := cmd.Exec(ctx, []string{d, "another/argument"})
 			c.Expect(err, Not(IsNil))
 			c.Expect(err.Error(), Equals, "too many arguments")
 		})
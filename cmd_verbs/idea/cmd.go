@@ -0,0 +1,512 @@
+package idea
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghthor/journal/config"
+	"github.com/ghthor/journal/entry"
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/idea"
+	"github.com/ghthor/journal/lock"
+)
+
+var Cmd = NewCmd(nil)
+
+type cmd struct {
+	flagSet *flag.FlagSet
+
+	wd string // working directory
+
+	// Output for reporting what was done
+	Stdout io.Writer
+
+	// Creates the process used to edit the
+	// file an idea is split in. Defaults to $EDITOR.
+	EditorFor func(filename string) (entry.EditorProcess, error)
+}
+
+// A subcommand of the `idea` verb
+type subcommand struct {
+	summary string
+	exec    func(c *cmd, ctx context.Context, args []string) error
+}
+
+var subcommands map[string]subcommand
+
+func init() {
+	subcommands = map[string]subcommand{
+		"renumber": {"resolve ideas created with the same id on both sides of a merge", (*cmd).renumber},
+		"move":     {"move an active idea before another active idea", (*cmd).move},
+		"merge":    {"merge an idea into another idea", (*cmd).merge},
+		"split":    {"split part of an idea into a new idea", (*cmd).split},
+	}
+}
+
+var ErrNoSubcommand = errors.New("no subcommand")
+
+func NewCmd(flagSet *flag.FlagSet) *cmd {
+	c := &cmd{
+		flagSet: flagSet,
+		Stdout:  os.Stdout,
+		EditorFor: func(filename string) (entry.EditorProcess, error) {
+			return entry.NewEnvEditor(os.Getenv("EDITOR"), filename)
+		},
+	}
+
+	if c.flagSet == nil {
+		c.flagSet = flag.NewFlagSet("idea", flag.ExitOnError)
+		c.flagSet.Usage = c.usage
+	}
+
+	return c
+}
+
+func (c *cmd) SetWd(directory string) {
+	c.wd = directory
+}
+
+func (c *cmd) usage() {
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(c.Stdout, "Usage: journal idea subcommand [arguments]\n\nThe subcommands are:")
+	for _, name := range names {
+		fmt.Fprintf(c.Stdout, "    %-10s %s\n", name, subcommands[name].summary)
+	}
+}
+
+func (c *cmd) Exec(ctx context.Context, args []string) error {
+	c.flagSet.Parse(args)
+
+	a := c.flagSet.Args()
+	if len(a) == 0 {
+		c.usage()
+		return ErrNoSubcommand
+	}
+
+	sub, exists := subcommands[a[0]]
+	if !exists {
+		c.usage()
+		return fmt.Errorf("unknown subcommand `%s`", a[0])
+	}
+
+	return sub.exec(c, ctx, a[1:])
+}
+
+// Returns the journal directory using the
+// optional directory argument
+func (c *cmd) journalDir(args []string) (string, error) {
+	var path string
+
+	switch len(args) {
+	case 0:
+		path = c.wd
+	case 1:
+		path = args[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.wd, path)
+		}
+
+	default:
+		return "", errors.New("too many arguments")
+	}
+
+	return path, nil
+}
+
+func (c *cmd) renumber(ctx context.Context, args []string) error {
+	path, err := c.journalDir(args)
+	if err != nil {
+		return err
+	}
+
+	// Hold the journal lock until the changes have been committed
+	l, err := lock.Journal(path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := cfg.IdeaStore(path)
+	if err != nil {
+		return err
+	}
+
+	renumbering, err := store.RenumberDuplicates(ctx)
+	if err != nil {
+		return err
+	}
+
+	entryChanges, err := entry.RenumberIdeasIn(ctx, filepath.Join(path, "entry"), renumbering.Renumbered)
+	if err != nil {
+		return err
+	}
+
+	// Stage the resolution, the merge is concluded by the user.
+	// Resolving a merge requires git so the exec backend is always used.
+	for _, changes := range []git.Commitable{renumbering, entryChanges} {
+		for _, change := range changes.Changes() {
+			err := git.AddFilepath(ctx, changes.WorkingDirectory(), change.Filepath())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(renumbering.Renumbered))
+	for from, to := range renumbering.Renumbered {
+		ids = append(ids, fmt.Sprintf("renumbered idea %d -> %d", from, to))
+	}
+	sort.Strings(ids)
+
+	fmt.Fprintln(c.Stdout, strings.Join(ids, "\n"))
+	fmt.Fprintln(c.Stdout, "the resolution has been staged, run `git commit` to conclude the merge")
+
+	return nil
+}
+
+func (c *cmd) move(ctx context.Context, args []string) error {
+	flagSet := flag.NewFlagSet("move", flag.ContinueOnError)
+	flagSet.SetOutput(c.Stdout)
+
+	var before uint
+	flagSet.UintVar(&before, "before", 0, "the id of the active idea to move the idea before")
+
+	// Flags may follow the id of the idea being moved
+	err := flagSet.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	a := flagSet.Args()
+	if len(a) == 0 {
+		return errors.New("usage: journal idea move <id> --before <id> [directory]")
+	}
+
+	id, err := strconv.ParseUint(a[0], 10, 0)
+	if err != nil {
+		return fmt.Errorf("invalid idea id: %s", a[0])
+	}
+
+	err = flagSet.Parse(a[1:])
+	if err != nil {
+		return err
+	}
+
+	if before == 0 {
+		return errors.New("the id to move the idea before must be set with --before")
+	}
+
+	path, err := c.journalDir(flagSet.Args())
+	if err != nil {
+		return err
+	}
+
+	// Hold the journal lock until the changes have been committed
+	l, err := lock.Journal(path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := cfg.IdeaStore(path)
+	if err != nil {
+		return err
+	}
+
+	repo, err := cfg.Repository(path)
+	if err != nil {
+		return err
+	}
+
+	startedAt, err := headOfClean(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	err = commitOrRollBack(ctx, repo, path, startedAt, func() (git.Commitable, error) {
+		return store.MoveActive(uint(id), before)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stdout, "moved idea %d before %d\n", id, before)
+
+	return nil
+}
+
+// Returned if the journal has changes that haven't been committed
+var ErrGitIsDirty = errors.New("git is dirty")
+
+// Returns the hash of HEAD if the journal doesn't have any changes
+// that haven't been committed. Otherwise returns ErrGitIsDirty, the
+// changes would be committed with the changes made to the ideas.
+func headOfClean(ctx context.Context, repo git.Repository) (string, error) {
+	status, err := repo.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if len(status) != 0 {
+		return "", ErrGitIsDirty
+	}
+
+	return repo.RevParse(ctx, "HEAD")
+}
+
+// Makes the changes to the ideas and commits them. If either fails the
+// journal in path is reset to startedAt, so nothing is left half changed.
+func commitOrRollBack(ctx context.Context, repo git.Repository, path, startedAt string, change func() (git.Commitable, error)) error {
+	changes, err := change()
+	if err == nil {
+		err = git.CommitTo(ctx, repo, changes)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	// The rollback isn't canceled so it completes after an interrupt.
+	// The journal was clean so every change is staged, including the
+	// ideas that were created, and then removed by the reset.
+	rollback := context.WithoutCancel(ctx)
+	resetErr := repo.Add(rollback, path)
+	if resetErr == nil {
+		resetErr = repo.Reset(rollback, startedAt, git.HardReset)
+	}
+
+	if resetErr != nil {
+		return fmt.Errorf("%v, and rolling back to %s failed: %v", err, startedAt, resetErr)
+	}
+
+	return err
+}
+
+func parseIdeaId(arg string) (uint, error) {
+	id, err := strconv.ParseUint(arg, 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid idea id: %s", arg)
+	}
+	return uint(id), nil
+}
+
+func (c *cmd) merge(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: journal idea merge <id> <into id> [directory]")
+	}
+
+	srcId, err := parseIdeaId(args[0])
+	if err != nil {
+		return err
+	}
+
+	dstId, err := parseIdeaId(args[1])
+	if err != nil {
+		return err
+	}
+
+	path, err := c.journalDir(args[2:])
+	if err != nil {
+		return err
+	}
+
+	// Hold the journal lock until the changes have been committed
+	l, err := lock.Journal(path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := cfg.IdeaStore(path)
+	if err != nil {
+		return err
+	}
+
+	repo, err := cfg.Repository(path)
+	if err != nil {
+		return err
+	}
+
+	startedAt, err := headOfClean(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	err = commitOrRollBack(ctx, repo, path, startedAt, func() (git.Commitable, error) {
+		return store.MergeInto(srcId, dstId)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stdout, "merged idea %d into %d\n", srcId, dstId)
+
+	return nil
+}
+
+// Returned when the file an idea was split in doesn't
+// contain the idea followed by exactly one new idea
+var ErrInvalidSplit = errors.New("the idea must be followed by exactly one new idea w/o an id")
+
+func (c *cmd) split(ctx context.Context, args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: journal idea split <id> [directory]")
+	}
+
+	id, err := parseIdeaId(args[0])
+	if err != nil {
+		return err
+	}
+
+	path, err := c.journalDir(args[1:])
+	if err != nil {
+		return err
+	}
+
+	// Hold the journal lock until the changes have been committed
+	l, err := lock.Journal(path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := cfg.IdeaStore(path)
+	if err != nil {
+		return err
+	}
+
+	repo, err := cfg.Repository(path)
+	if err != nil {
+		return err
+	}
+
+	// Checked before the idea is edited so the edit isn't lost
+	startedAt, err := headOfClean(ctx, repo)
+	if err != nil {
+		return err
+	}
+
+	original, err := store.IdeaById(id)
+	if err != nil {
+		return err
+	}
+
+	template := idea.Idea{
+		Status: original.Status,
+		Name:   fmt.Sprintf("Split from [%d] %s", original.Id, original.Name),
+	}
+
+	// The idea and the new idea are edited in a temporary file
+	tmpDir, err := ioutil.TempDir("", "journal_split_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	data := make([]byte, 0, len(original.Body)+256)
+	for i, ideaToEdit := range []idea.Idea{original, template} {
+		encoded, err := idea.Encode(ideaToEdit)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			data = append(data, '\n')
+		}
+		data = append(data, encoded...)
+	}
+
+	filename := filepath.Join(tmpDir, fmt.Sprintf("idea-%d.md", id))
+	err = ioutil.WriteFile(filename, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	editor, err := c.EditorFor(filename)
+	if err != nil {
+		return err
+	}
+
+	err = editor.Start()
+	if err != nil {
+		return err
+	}
+
+	err = editor.Wait()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ideas := make([]idea.Idea, 0, 2)
+	scanner := idea.NewIdeaScanner(f)
+	for scanner.Scan() {
+		ideas = append(ideas, *scanner.Idea())
+	}
+
+	if scanner.Err() != nil {
+		return scanner.Err()
+	}
+
+	if len(ideas) != 2 || ideas[0].Id != original.Id || ideas[1].Id != 0 {
+		return ErrInvalidSplit
+	}
+
+	if ideas[1] == template {
+		return errors.New("the new idea wasn't modified, nothing was split")
+	}
+
+	err = commitOrRollBack(ctx, repo, path, startedAt, func() (git.Commitable, error) {
+		return store.SplitIdea(ctx, ideas[0], &ideas[1])
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Stdout, "split idea %d from %d\n", ideas[1].Id, original.Id)
+
+	return nil
+}
+
+func (c cmd) Summary() string {
+	return "manage the ideas stored in a journal"
+}
@@ -0,0 +1,377 @@
+package idea
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghthor/journal/config"
+	"github.com/ghthor/journal/entry"
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/idea"
+	initialize "github.com/ghthor/journal/init"
+	"github.com/ghthor/journal/lock"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+// Replaces the contents of the file when the editor is started
+type mockEditor struct {
+	filename string
+	data     []byte
+}
+
+func (m mockEditor) Start() error {
+	return ioutil.WriteFile(m.filename, m.data, 0600)
+}
+
+func (m mockEditor) Wait() error {
+	return nil
+}
+
+func DescribeIdeaCmd(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("the `idea` command", func() {
+		// Create a temporary journal
+		journalDir, err := ioutil.TempDir("", "idea_cmd_desc_")
+		c.Assume(err, IsNil)
+		defer func() {
+			c.Assume(os.RemoveAll(journalDir), IsNil)
+		}()
+
+		commitable, err := initialize.Journal(ctx, journalDir)
+		c.Assume(err, IsNil)
+		c.Assume(git.Commit(ctx, commitable), IsNil)
+
+		store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+		c.Assume(err, IsNil)
+
+		saveIdea := func(i idea.Idea) idea.Idea {
+			commitable, err := store.SaveIdea(ctx, &i)
+			c.Assume(err, IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
+			return i
+		}
+
+		gitCmd := func(args ...string) string {
+			o, err := git.Command(journalDir, args...).CombinedOutput()
+			c.Assume(err, IsNil)
+			return string(o)
+		}
+
+		cmd := NewCmd(nil)
+		cmd.SetWd(journalDir)
+		cmd.Stdout = ioutil.Discard
+
+		c.Specify("will fail without a subcommand", func() {
+			c.Expect(cmd.Exec(ctx, nil), Equals, ErrNoSubcommand)
+		})
+
+		c.Specify("will renumber ideas created with the same id on both sides of a merge", func() {
+			base := gitCmd("rev-parse", "--abbrev-ref", "HEAD")
+			base = base[:len(base)-1]
+
+			// Create an idea on another branch
+			gitCmd("checkout", "-q", "-b", "theirs")
+			theirs := saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "their idea",
+				Body:   "their idea body\n",
+			})
+
+			// Reference their idea in an entry
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "entry", "2015-01-01-0000-UTC"),
+				[]byte("## [active] [1] their idea\n"), 0600), IsNil)
+			gitCmd("add", "entry")
+			gitCmd("commit", "-q", "-m", "their entry")
+
+			// Create an idea with the same id on our branch
+			gitCmd("checkout", "-q", base)
+			ours := saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "our idea",
+				Body:   "our idea body\n",
+			})
+			c.Assume(ours.Id, Equals, theirs.Id)
+
+			// Merge will conflict on the idea file
+			_, err := git.Command(journalDir, "merge", "theirs").CombinedOutput()
+			c.Assume(err, Not(IsNil))
+
+			c.Assume(cmd.Exec(ctx, []string{"renumber"}), IsNil)
+
+			// Concludes the merge
+			gitCmd("commit", "-q", "--no-edit")
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+			c.Specify("and keep our idea's id", func() {
+				actual, err := store.IdeaById(1)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, ours)
+			})
+
+			c.Specify("and assign their idea the next available id", func() {
+				theirs.Id = 2
+
+				actual, err := store.IdeaById(2)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, theirs)
+
+				nextid, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "nextid"))
+				c.Assume(err, IsNil)
+				c.Expect(string(nextid), Equals, "3\n")
+			})
+
+			c.Specify("and include both ideas in the active index", func() {
+				active, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "active"))
+				c.Assume(err, IsNil)
+				c.Expect(string(active), Equals, "1\n2\n")
+			})
+
+			c.Specify("and update the references in their entries", func() {
+				data, err := ioutil.ReadFile(filepath.Join(journalDir, "entry", "2015-01-01-0000-UTC"))
+				c.Assume(err, IsNil)
+				c.Expect(string(data), Equals, "## [active] [2] their idea\n")
+			})
+		})
+
+		c.Specify("will move an active idea before another active idea", func() {
+			for _, name := range []string{"first", "second", "third"} {
+				saveIdea(idea.Idea{
+					Status: idea.IS_Active,
+					Name:   name,
+					Body:   name + " body\n",
+				})
+			}
+
+			c.Assume(cmd.Exec(ctx, []string{"move", "3", "--before", "1"}), IsNil)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+			active, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "active"))
+			c.Assume(err, IsNil)
+			c.Expect(string(active), Equals, "3\n1\n2\n")
+
+			c.Expect(gitCmd("show", "-s", "--format=%s"), Equals, "idea - moved - 3 before 1\n")
+
+			c.Specify("and will fail if the idea isn't active", func() {
+				c.Expect(cmd.Exec(ctx, []string{"move", "4", "--before", "1"}), Equals, idea.IdeaNotActiveError{Id: 4})
+			})
+		})
+
+		c.Specify("will merge an idea into another idea", func() {
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "a duplicate",
+				Body:   "- [ ] a task\n- [ ] another task\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "the idea",
+				Body:   "- [ ] a task\n",
+			})
+
+			c.Assume(cmd.Exec(ctx, []string{"merge", "1", "2"}), IsNil)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+			c.Expect(gitCmd("show", "-s", "--format=%s"), Equals, "idea - merged - 1 into 2\n")
+
+			merged, err := store.IdeaById(1)
+			c.Assume(err, IsNil)
+			c.Expect(merged.Status, Equals, idea.IS_Merged)
+			c.Expect(merged.Body, Equals, "- [ ] a task\n- [ ] another task\nMerged-Into: 2\n")
+
+			into, err := store.IdeaById(2)
+			c.Assume(err, IsNil)
+			c.Expect(into.Body, Equals, "- [ ] a task\n\nMerged from [1] a duplicate\n- [ ] another task\n")
+
+			active, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "active"))
+			c.Assume(err, IsNil)
+			c.Expect(string(active), Equals, "2\n")
+
+			c.Specify("and will fail if the idea has already been merged", func() {
+				c.Expect(cmd.Exec(ctx, []string{"merge", "1", "2"}), Equals, idea.AlreadyMergedError{Id: 1})
+			})
+		})
+
+		c.Specify("will split part of an idea into a new idea", func() {
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "the idea",
+				Body:   "part of the idea\nanother idea\n",
+			})
+
+			var edited []byte
+			cmd.EditorFor = func(filename string) (entry.EditorProcess, error) {
+				data, err := ioutil.ReadFile(filename)
+				c.Assume(err, IsNil)
+				c.Expect(string(data), Equals, "## [active] [1] the idea\npart of the idea\nanother idea\n\n## [active] Split from [1] the idea\n")
+				return mockEditor{filename, edited}, nil
+			}
+
+			c.Specify("and commit both ideas", func() {
+				edited = []byte("## [active] [1] the idea\npart of the idea\n\n## [active] another idea\nsplit from the idea\n")
+
+				c.Assume(cmd.Exec(ctx, []string{"split", "1"}), IsNil)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+				c.Expect(gitCmd("show", "-s", "--format=%s"), Equals, "idea - split - 2 from 1\n")
+
+				original, err := store.IdeaById(1)
+				c.Assume(err, IsNil)
+				c.Expect(original.Body, Equals, "part of the idea\n")
+
+				split, err := store.IdeaById(2)
+				c.Assume(err, IsNil)
+				c.Expect(split, Equals, idea.Idea{
+					Status: idea.IS_Active,
+					Id:     2,
+					Name:   "another idea",
+					Body:   "split from the idea\n",
+				})
+
+				active, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "active"))
+				c.Assume(err, IsNil)
+				c.Expect(string(active), Equals, "1\n2\n")
+			})
+
+			c.Specify("and will fail if there isn't exactly one new idea", func() {
+				edited = []byte("## [active] [1] the idea\npart of the idea\n")
+
+				c.Expect(cmd.Exec(ctx, []string{"split", "1"}), Equals, ErrInvalidSplit)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+			})
+		})
+
+		c.Specify("will fail if the journal has changes that haven't been committed", func() {
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "first",
+				Body:   "first body\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "second",
+				Body:   "second body\n",
+			})
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "uncommitted"), []byte("a change\n"), 0644), IsNil)
+
+			editorStarted := false
+			cmd.EditorFor = func(filename string) (entry.EditorProcess, error) {
+				editorStarted = true
+				return mockEditor{filename, nil}, nil
+			}
+
+			c.Expect(cmd.Exec(ctx, []string{"move", "2", "--before", "1"}), Equals, ErrGitIsDirty)
+			c.Expect(cmd.Exec(ctx, []string{"merge", "1", "2"}), Equals, ErrGitIsDirty)
+			c.Expect(cmd.Exec(ctx, []string{"split", "1"}), Equals, ErrGitIsDirty)
+			c.Expect(editorStarted, IsFalse)
+		})
+
+		c.Specify("will fail if the journal is locked by another process", func() {
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "first",
+				Body:   "first body\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "second",
+				Body:   "second body\n",
+			})
+
+			// The parent process is guaranteed to be alive
+			lockFile := filepath.Join(journalDir, ".git", lock.Filename)
+			c.Assume(ioutil.WriteFile(lockFile, []byte(fmt.Sprintln(os.Getppid())), 0600), IsNil)
+
+			editorStarted := false
+			cmd.EditorFor = func(filename string) (entry.EditorProcess, error) {
+				editorStarted = true
+				return mockEditor{filename, nil}, nil
+			}
+
+			for _, args := range [][]string{
+				{"move", "2", "--before", "1"},
+				{"merge", "1", "2"},
+				{"split", "1"},
+				{"renumber"},
+			} {
+				err := cmd.Exec(ctx, args)
+				c.Expect(lock.IsLockedError(err), IsTrue)
+			}
+			c.Expect(editorStarted, IsFalse)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+		})
+
+		c.Specify("will roll back the changes if they can't be committed", func() {
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "a duplicate",
+				Body:   "- [ ] a task\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "the idea",
+				Body:   "- [ ] another task\n",
+			})
+
+			// A message that can't be rendered
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, config.Filename), []byte(`{"git": {"messages": {"default": "{{.Missing}}"}}}`), 0644), IsNil)
+			gitCmd("add", config.Filename)
+			gitCmd("commit", "-m", "configure the commit messages")
+			head := gitCmd("rev-parse", "HEAD")
+
+			c.Expect(cmd.Exec(ctx, []string{"merge", "1", "2"}), Not(IsNil))
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+			c.Expect(gitCmd("rev-parse", "HEAD"), Equals, head)
+
+			src, err := store.IdeaById(1)
+			c.Assume(err, IsNil)
+			c.Expect(src.Status, Equals, idea.IS_Active)
+
+			active, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "active"))
+			c.Assume(err, IsNil)
+			c.Expect(string(active), Equals, "1\n2\n")
+		})
+
+		c.Specify("will fail to merge an idea that can't be changed to merged", func() {
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, config.Filename), []byte(`{"statuses": [
+				{"name": "active", "carryOver": true, "transitions": ["completed"]},
+				{"name": "completed"}
+			]}`), 0644), IsNil)
+			gitCmd("add", config.Filename)
+			gitCmd("commit", "-m", "configure the idea statuses")
+
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "a duplicate",
+				Body:   "- [ ] a task\n",
+			})
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "the idea",
+				Body:   "- [ ] another task\n",
+			})
+
+			c.Expect(cmd.Exec(ctx, []string{"merge", "1", "2"}), Equals, idea.InvalidTransitionError{
+				Id:   1,
+				From: idea.IS_Active,
+				To:   idea.IS_Merged,
+			})
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+		})
+
+		c.Specify("will fail to renumber if there are no duplicate ids", func() {
+			saveIdea(idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "an idea",
+				Body:   "an idea body\n",
+			})
+
+			c.Expect(cmd.Exec(ctx, []string{"renumber"}), Equals, idea.ErrNoDuplicateIds)
+			c.Expect(bytes.Contains([]byte(gitCmd("status", "-s")), []byte("idea")), IsFalse)
+		})
+	})
+}
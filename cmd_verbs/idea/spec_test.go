@@ -0,0 +1,14 @@
+package idea
+
+import (
+	"github.com/ghthor/gospec"
+	"testing"
+)
+
+func TestUnitSpecs(t *testing.T) {
+	r := gospec.NewRunner()
+
+	r.AddSpec(DescribeIdeaCmd)
+
+	gospec.MainGoTest(r, t)
+}
@@ -1,12 +1,15 @@
 package init
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"path/filepath"
 
+	"github.com/ghthor/journal/config"
 	"github.com/ghthor/journal/git"
 	initialize "github.com/ghthor/journal/init"
+	"github.com/ghthor/journal/lock"
 )
 
 var Cmd = NewCmd(nil)
@@ -45,7 +48,13 @@ func (c journalInitCommit) CommitMsg() string {
 	return "journal - init - " + c.Commitable.CommitMsg()
 }
 
-func (c *cmd) Exec(args []string) error {
+func (c journalInitCommit) CommitDescription() git.Description {
+	d := c.Commitable.CommitDescription()
+	d.Kind = git.InitCommit
+	return d
+}
+
+func (c *cmd) Exec(ctx context.Context, args []string) error {
 	c.flagSet.Parse(args)
 
 	a := c.flagSet.Args()
@@ -65,23 +74,39 @@ func (c *cmd) Exec(args []string) error {
 		return errors.New("too many arguments")
 	}
 
-	commitable, err := initialize.Journal(path)
+	commitable, err := initialize.Journal(ctx, path)
 	if err != nil {
 		return err
 	}
 
 	if !c.noCommit {
-		err := git.CommitEmpty(path, "journal - init - begin")
+		l, err := lock.Journal(path)
+		if err != nil {
+			return err
+		}
+		defer l.Release()
+
+		cfg, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+
+		repo, err := cfg.Repository(path)
+		if err != nil {
+			return err
+		}
+
+		err = git.CommitEmptyTo(ctx, repo, journalInitCommit{&git.Changes{Dir: path, Msg: "begin"}})
 		if err != nil {
 			return err
 		}
 
-		err = git.Commit(journalInitCommit{commitable})
+		err = git.CommitTo(ctx, repo, journalInitCommit{commitable})
 		if err != nil {
 			return err
 		}
 
-		err = git.CommitEmpty(path, "journal - init - completed")
+		err = git.CommitEmptyTo(ctx, repo, journalInitCommit{&git.Changes{Dir: path, Msg: "completed"}})
 		if err != nil {
 			return err
 		}
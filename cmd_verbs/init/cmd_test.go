@@ -1,6 +1,7 @@
 package init_test
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -15,6 +16,8 @@ import (
 )
 
 func DescribeInitCmd(c gospec.Context) {
+	ctx := context.Background()
+
 	var tmpDir = func() (directory string, cleanUp func()) {
 		directory, err := ioutil.TempDir("", "journal-cmd_verbs-init")
 		c.Assume(err, IsNil)
@@ -30,9 +33,9 @@ func DescribeInitCmd(c gospec.Context) {
 		c.Assume(initialize.HasBeenInitialized(directory), IsFalse)
 
 		c.Specify("and commit the modifications to git", func() {
-			c.Expect(cmd.Exec(args), IsNil)
+			c.Expect(cmd.Exec(ctx, args), IsNil)
 			c.Expect(initialize.HasBeenInitialized(directory), IsTrue)
-			c.Expect(git.IsClean(directory), IsNil)
+			c.Expect(git.IsClean(ctx, directory), IsNil)
 			// TODO check commit messages
 		})
 
@@ -41,9 +44,9 @@ func DescribeInitCmd(c gospec.Context) {
 			gitargs = append(gitargs, "-no-commit")
 			gitargs = append(gitargs, args...)
 
-			c.Expect(cmd.Exec(gitargs), IsNil)
+			c.Expect(cmd.Exec(ctx, gitargs), IsNil)
 			c.Expect(initialize.HasBeenInitialized(directory), IsTrue)
-			c.Expect(git.IsClean(directory), Not(IsNil))
+			c.Expect(git.IsClean(ctx, directory), Not(IsNil))
 		})
 	}
 
@@ -76,7 +79,7 @@ func DescribeInitCmd(c gospec.Context) {
 		c.Specify("will error with too many arguments", func() {
 			cmd := initVerb.NewCmd(nil)
 
-			err := cmd.Exec([]string{d, "another/argument"})
+			err := cmd.Exec(ctx, []string{d, "another/argument"})
 			c.Expect(err, Not(IsNil))
 			c.Expect(err.Error(), Equals, "too many arguments")
 		})
@@ -0,0 +1,105 @@
+package mergedriver
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/ghthor/journal/idea"
+)
+
+var Cmd = NewCmd(nil)
+
+type cmd struct {
+	flagSet *flag.FlagSet
+
+	wd string // working directory
+}
+
+// Merges the contents of a file from the base, our
+// and their side of a merge into the contents of our side
+type mergeFunc func(base, ours, theirs []byte) ([]byte, error)
+
+var drivers = map[string]mergeFunc{
+	"active": idea.MergeActiveIndex,
+	"nextid": idea.MergeNextId,
+}
+
+// Returned when the merge driver requested doesn't exist
+type UnknownDriverError struct {
+	Driver string
+}
+
+func (e UnknownDriverError) Error() string {
+	return fmt.Sprintf("unknown merge driver: %s", e.Driver)
+}
+
+func IsUnknownDriverError(err error) bool {
+	_, ok := err.(UnknownDriverError)
+	return ok
+}
+
+func NewCmd(flagSet *flag.FlagSet) *cmd {
+	if flagSet == nil {
+		flagSet = flag.NewFlagSet("merge-driver", flag.ExitOnError)
+	}
+
+	c := &cmd{
+		flagSet: flagSet,
+	}
+
+	return c
+}
+
+func (c *cmd) SetWd(directory string) {
+	c.wd = directory
+}
+
+// Executed by git as `journal merge-driver {active|nextid} %O %A %B`.
+// The merge is written to our file, which git uses as the result.
+func (c *cmd) Exec(ctx context.Context, args []string) error {
+	c.flagSet.Parse(args)
+
+	a := c.flagSet.Args()
+	if len(a) != 4 {
+		return errors.New("usage: merge-driver {active|nextid} base ours theirs")
+	}
+
+	merge, exists := drivers[a[0]]
+	if !exists {
+		return UnknownDriverError{a[0]}
+	}
+
+	files := make([][]byte, 0, 3)
+	for _, path := range a[1:] {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.wd, path)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, data)
+	}
+
+	merged, err := merge(files[0], files[1], files[2])
+	if err != nil {
+		return err
+	}
+
+	ours := a[2]
+	if !filepath.IsAbs(ours) {
+		ours = filepath.Join(c.wd, ours)
+	}
+
+	return ioutil.WriteFile(ours, merged, 0644)
+}
+
+func (c cmd) Summary() string {
+	return "merge the idea store's index files, executed by git"
+}
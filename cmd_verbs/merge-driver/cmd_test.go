@@ -0,0 +1,70 @@
+package mergedriver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeMergeDriverCmd(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("the `merge-driver` command", func() {
+		d, err := ioutil.TempDir("", "merge_driver_cmd_desc_")
+		c.Assume(err, IsNil)
+		defer func() {
+			c.Assume(os.RemoveAll(d), IsNil)
+		}()
+
+		// Write the 3 versions the way git passes them to a driver
+		writeFiles := func(base, ours, theirs string) []string {
+			c.Assume(ioutil.WriteFile(filepath.Join(d, "base"), []byte(base), 0600), IsNil)
+			c.Assume(ioutil.WriteFile(filepath.Join(d, "ours"), []byte(ours), 0600), IsNil)
+			c.Assume(ioutil.WriteFile(filepath.Join(d, "theirs"), []byte(theirs), 0600), IsNil)
+			return []string{"base", "ours", "theirs"}
+		}
+
+		result := func() string {
+			data, err := ioutil.ReadFile(filepath.Join(d, "ours"))
+			c.Assume(err, IsNil)
+			return string(data)
+		}
+
+		cmd := NewCmd(nil)
+		cmd.SetWd(d)
+
+		c.Specify("will union the active ideas", func() {
+			files := writeFiles("1\n2\n", "1\n2\n3\n", "2\n4\n")
+
+			c.Assume(cmd.Exec(ctx, append([]string{"active"}, files...)), IsNil)
+			c.Expect(result(), Equals, "2\n3\n4\n")
+		})
+
+		c.Specify("will keep the greater next id", func() {
+			files := writeFiles("3\n", "4\n", "6\n")
+
+			c.Assume(cmd.Exec(ctx, append([]string{"nextid"}, files...)), IsNil)
+			c.Expect(result(), Equals, "6\n")
+		})
+
+		c.Specify("will fail", func() {
+			c.Specify("with a driver that doesn't exist", func() {
+				files := writeFiles("", "", "")
+
+				err := cmd.Exec(ctx, append([]string{"entry"}, files...))
+				c.Expect(IsUnknownDriverError(err), IsTrue)
+			})
+
+			c.Specify("with an index that can't be parsed", func() {
+				files := writeFiles("3\n", "4\n", "<<<<<<<\n")
+
+				c.Expect(cmd.Exec(ctx, append([]string{"nextid"}, files...)), Not(IsNil))
+				c.Expect(result(), Equals, "4\n")
+			})
+		})
+	})
+}
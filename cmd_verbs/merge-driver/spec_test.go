@@ -0,0 +1,14 @@
+package mergedriver
+
+import (
+	"github.com/ghthor/gospec"
+	"testing"
+)
+
+func TestUnitSpecs(t *testing.T) {
+	r := gospec.NewRunner()
+
+	r.AddSpec(DescribeMergeDriverCmd)
+
+	gospec.MainGoTest(r, t)
+}
@@ -1,18 +1,24 @@
 package new
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ghthor/journal/config"
 	"github.com/ghthor/journal/entry"
 	"github.com/ghthor/journal/git"
 	"github.com/ghthor/journal/idea"
+	"github.com/ghthor/journal/lock"
 )
 
 var Cmd = NewCmd(nil)
@@ -21,15 +27,55 @@ type cmd struct {
 	EditorProcess entry.EditorProcess
 	Now           func() time.Time
 
+	// Used to ask if a new idea is a duplicate of an existing idea
+	Stdin  io.Reader
+	Stdout io.Writer
+
 	flagSet *flag.FlagSet
 
 	wd string // working directory
 
+	// Ideas to bring back into the entry
+	ideaIds *idsFlag
+
+	// Overrides the configured commit style
+	commits *string
+
 	// noCommit bool
 }
 
 var ErrGitIsDirty = errors.New("git is dirty")
 
+// Returned if ideas were modified while the entry was open
+// and the changes conflict with the changes made in the entry
+type MergeConflictError struct {
+	Filename string
+	Ids      []uint
+}
+
+func (e MergeConflictError) Error() string {
+	return fmt.Sprintf("ideas %v were modified while the entry was open, the conflicts have been written into %s", e.Ids, e.Filename)
+}
+
+// A list of idea ids that can be set with a flag
+// more than once or as a comma separated list
+type idsFlag []uint
+
+func (ids *idsFlag) String() string {
+	return fmt.Sprint([]uint(*ids))
+}
+
+func (ids *idsFlag) Set(value string) error {
+	for _, s := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid idea id: %s", s)
+		}
+		*ids = append(*ids, uint(id))
+	}
+	return nil
+}
+
 func NewCmd(flagSet *flag.FlagSet) *cmd {
 	if flagSet == nil {
 		flagSet = flag.NewFlagSet("new", flag.ExitOnError)
@@ -37,8 +83,12 @@ func NewCmd(flagSet *flag.FlagSet) *cmd {
 
 	c := &cmd{
 		flagSet: flagSet,
+		ideaIds: &idsFlag{},
 	}
 
+	c.flagSet.Var(c.ideaIds, "idea", "the id of an idea to include in the entry and activate, may be repeated")
+	c.commits = c.flagSet.String("commits", "", "commit the ideas and the entry \"separate\" or \"combined\", overrides journal.json")
+
 	//c.flagSet.BoolVar(&c.noCommit, "no-commit", false, "don't commit the new entry to the git repository")
 
 	return c
@@ -48,43 +98,7 @@ func (c *cmd) SetWd(directory string) {
 	c.wd = directory
 }
 
-func newEnvEditor(envEditor string, entryFilename string) (*exec.Cmd, error) {
-	// Enable the $EDITOR variable to
-	// contain a string such a "emacs -nw"
-	editorArgs := strings.Split(envEditor, " ")
-
-	// Assume that the first item in the split list
-	// is the executable name, such as editorArgs[0] == "vim"
-	// and look it up.
-	editorBin, err := exec.LookPath(editorArgs[0])
-	if err != nil {
-		return nil, err
-	}
-
-	var editorCmd *exec.Cmd
-
-	// Create an *exec.Cmd that will be used to edit the entry
-	switch editorArgs[0] {
-	case "vim":
-		editorCmd = exec.Command(editorBin, "+set spell", entryFilename)
-	case "emacs":
-		// ignore the "emacs" token from the editorArgs slice
-		// and append the entry filename to the end of it
-		editorArgs = append(editorArgs[1:], entryFilename)
-		editorCmd = exec.Command(editorBin, editorArgs...)
-	default:
-		// Support for an editor is explicit
-		return nil, fmt.Errorf("%v is unimplemented", editorBin)
-	}
-
-	editorCmd.Stdout = os.Stdout
-	editorCmd.Stderr = os.Stderr
-	editorCmd.Stdin = os.Stdin
-
-	return editorCmd, nil
-}
-
-func (c cmd) Exec(args []string) error {
+func (c cmd) Exec(ctx context.Context, args []string) error {
 	c.flagSet.Parse(args)
 
 	a := c.flagSet.Args()
@@ -104,21 +118,65 @@ func (c cmd) Exec(args []string) error {
 		return errors.New("too many arguments")
 	}
 
-	if git.IsClean(path) != nil {
+	// Hold the journal lock until the entry has been committed
+	l, err := lock.Journal(path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if len(*c.commits) != 0 {
+		err := config.ValidateCommits(*c.commits)
+		if err != nil {
+			return err
+		}
+		cfg.Git.Commits = *c.commits
+	}
+
+	repo, err := cfg.Repository(path)
+	if err != nil {
+		return err
+	}
+
+	status, err := repo.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(status) != 0 {
 		return ErrGitIsDirty
 	}
 
+	// Where to roll back to if committing fails
+	startedAt, err := repo.RevParse(ctx, "HEAD")
+	if err != nil {
+		return err
+	}
+
 	// Set default time provider
 	if c.Now == nil {
 		c.Now = time.Now
 	}
 
+	if c.Stdin == nil {
+		c.Stdin = os.Stdin
+	}
+
+	if c.Stdout == nil {
+		c.Stdout = os.Stdout
+	}
+
 	openedAt := c.Now()
 	entryFilename := openedAt.Format(entry.FilenameLayout)
 
 	// Define the editor process using the $EDITOR variable
 	if c.EditorProcess == nil {
-		editorCmd, err := newEnvEditor(os.Getenv("EDITOR"), entryFilename)
+		editorCmd, err := entry.NewEnvEditor(os.Getenv("EDITOR"), entryFilename)
 		if err != nil {
 			return err
 		}
@@ -131,7 +189,7 @@ func (c cmd) Exec(args []string) error {
 	// Make a new entry
 	entry := entry.New(filepath.Join(path, "entry"))
 
-	ideaStore, err := idea.NewDirectoryStore(filepath.Join(path, "idea"))
+	ideaStore, err := cfg.IdeaStore(path)
 	if err != nil {
 		return err
 	}
@@ -141,6 +199,21 @@ func (c cmd) Exec(args []string) error {
 		return err
 	}
 
+	// Include the ideas requested with --idea
+	include := includeIdea(ideaStore, cfg.Statuses)
+	for _, id := range *c.ideaIds {
+		if hasIdea(ideas, id) {
+			continue
+		}
+
+		i, err := include(id)
+		if err != nil {
+			return err
+		}
+
+		ideas = append(ideas, i)
+	}
+
 	// Open entry w/ ideas
 	openEntry, err := entry.Open(openedAt, ideas)
 	if err != nil {
@@ -153,15 +226,143 @@ func (c cmd) Exec(args []string) error {
 		return fmt.Errorf("error during edit: %s", err)
 	}
 
+	// Expand any {{idea N}} directives written into the entry
+	err = openEntry.ExpandIdeaDirectives(include)
+	if err != nil {
+		return err
+	}
+
 	// Parse out the ideas
 	ideas, err = openEntry.Ideas()
 	if err != nil {
 		return err
 	}
 
+	// Merge with any changes made to the ideas while the entry was open
+	conflicts := make([]uint, 0, len(ideas))
+	for k, i := range ideas {
+		merged, err := ideaStore.MergeIdea(ctx, i)
+		if err != nil {
+			if e, ok := err.(idea.ConflictError); ok {
+				conflicts = append(conflicts, e.Id)
+				merged = e.Merged
+			} else {
+				return err
+			}
+		}
+
+		ideas[k] = merged
+	}
+
+	// Nothing is committed and the conflicts are left in the entry
+	if len(conflicts) > 0 {
+		err := openEntry.WriteIdeas(ideas)
+		if err != nil {
+			return err
+		}
+
+		return MergeConflictError{filepath.Join(path, "entry", entryFilename), conflicts}
+	}
+
+	// Offer to reuse the id of an existing idea
+	// if a new idea is a likely duplicate of it
+	err = c.reuseDuplicateIds(ideaStore, ideas)
+	if err != nil {
+		return err
+	}
+
+	// Keep what was written so it can be saved if committing fails
+	written, err := ioutil.ReadFile(filepath.Join(path, "entry", entryFilename))
+	if err != nil {
+		return err
+	}
+
+	err = commit(ctx, repo, cfg, ideaStore, openEntry, ideas, c.Now)
+	if err != nil {
+		// Undo any commits and changes to the ideas and the entry.
+		// The rollback isn't canceled so it completes after an interrupt.
+		// The journal was clean so every change is staged, including the
+		// entry and the ideas that were created, and then removed by the reset.
+		rollback := context.WithoutCancel(ctx)
+		resetErr := repo.Add(rollback, path)
+		if resetErr == nil {
+			resetErr = repo.Reset(rollback, startedAt, git.HardReset)
+		}
+
+		// Keep what was written outside of the journal so it stays clean
+		var saved string
+		if resetErr == nil {
+			saved, resetErr = saveUncommitted(path, entryFilename, written)
+		}
+
+		if resetErr != nil {
+			return fmt.Errorf("%v, and rolling back to %s failed: %v", err, startedAt, resetErr)
+		}
+
+		fmt.Fprintf(c.Stdout, "the entry wasn't committed, what was written is saved in %s\n", saved)
+
+		return err
+	}
+
+	return nil
+}
+
+// The directory in the git directory an entry that
+// couldn't be committed is saved in
+const UncommittedDir = "journal-uncommitted"
+
+// Saves what was written in an entry that couldn't be committed
+// into the journal's git directory and returns the path it's saved to
+func saveUncommitted(path, entryFilename string, written []byte) (string, error) {
+	gitDir, err := git.FindGitDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(gitDir, UncommittedDir)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	saved := filepath.Join(dir, entryFilename)
+	return saved, ioutil.WriteFile(saved, written, 0600)
+}
+
+// Closes the entry, saves the ideas to the store and commits
+// the changes. The entry is closed first so an entry that
+// can't be committed fails before anything is saved.
+func commit(ctx context.Context, repo git.Repository, cfg config.Config, store *idea.DirectoryStore, openEntry entry.OpenEntry, ideas []idea.Idea, now func() time.Time) error {
+	closedEntry, err := openEntry.Close(now())
+	if err != nil {
+		return err
+	}
+
+	// Date the commit with when the entry was written
+	entryCommit := cfg.CommitDates(closedEntry)
+	commits := cfg.Git.Commits
+
+	// Relates the commits of the ideas to the entry's commit
+	entryTrailer := git.Trailer{
+		Key:   git.EntryTrailer,
+		Value: filepath.ToSlash(filepath.Join("entry", openEntry.OpenedAt().Format(entry.FilenameLayout))),
+	}
+	ideaTrailers := make([]git.Trailer, 0, len(ideas))
+
+	combined := make([]git.Commitable, 0, len(ideas)+2)
+	commitChanges := func(commitable git.Commitable) error {
+		if commits == config.CombinedCommits {
+			combined = append(combined, commitable)
+			return nil
+		}
+		return git.CommitTo(ctx, repo, commitable)
+	}
+
 	// Save the ideas to the store
+	ids := make([]uint, 0, len(ideas))
 	for _, i := range ideas {
-		commitable, err := ideaStore.SaveIdea(&i)
+		commitable, err := store.SaveIdea(ctx, &i)
+		ids = append(ids, i.Id)
 		if err != nil {
 			if err == idea.ErrIdeaNotModified {
 				continue
@@ -170,26 +371,180 @@ func (c cmd) Exec(args []string) error {
 			return err
 		}
 
-		err = git.Commit(commitable)
+		switch commitable.CommitDescription().Kind {
+		case git.IdeaCreatedCommit:
+			ideaTrailers = append(ideaTrailers, git.Trailer{Key: git.IdeaCreatedTrailer, Value: fmt.Sprint(i.Id)})
+		case git.IdeaUpdatedCommit:
+			ideaTrailers = append(ideaTrailers, git.Trailer{Key: git.IdeaUpdatedTrailer, Value: fmt.Sprint(i.Id)})
+		}
+
+		if commits != config.CombinedCommits {
+			commitable = git.WithTrailers(commitable, entryTrailer)
+		}
+
+		err = commitChanges(commitable)
 		if err != nil {
 			return err
 		}
 	}
 
-	// Save the entry and commit it
-	closedEntry, err := openEntry.Close(c.Now())
-	if err != nil {
+	// Keep the active ideas in the order they were written in the entry
+	commitable, err := store.ReorderActive(ids)
+	if err != nil && err != idea.ErrActiveIndexNotModified {
 		return err
 	}
 
-	err = git.Commit(closedEntry)
-	if err != nil {
-		return err
+	if err == nil {
+		err = commitChanges(commitable)
+		if err != nil {
+			return err
+		}
+	}
+
+	if commits != config.CombinedCommits {
+		return git.CommitTo(ctx, repo, git.WithTrailers(entryCommit, ideaTrailers...))
+	}
+
+	// The entry's title is the subject and the
+	// changes to the ideas are listed in the body
+	msg := closedEntry.CommitMsg()
+	if len(combined) > 0 {
+		msg += "\n"
+		for _, c := range combined {
+			msg += "\n" + c.CommitMsg()
+		}
+	}
+
+	combined = append([]git.Commitable{entryCommit}, combined...)
+	trailers := append(ideaTrailers, entryTrailer)
+	return git.CommitTo(ctx, repo, git.WithTrailers(git.Combine(msg, combined...), trailers...))
+}
+
+func hasIdea(ideas []idea.Idea, id uint) bool {
+	for _, i := range ideas {
+		if i.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns a function that retrieves an idea by its id
+// and changes its status so it's carried over again
+func includeIdea(store *idea.DirectoryStore, statuses idea.Statuses) func(id uint) (idea.Idea, error) {
+	return func(id uint) (idea.Idea, error) {
+		i, err := store.IdeaById(id)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return idea.Idea{}, fmt.Errorf("idea %d doesn't exist", id)
+			}
+			return idea.Idea{}, err
+		}
+
+		if i.Status == idea.IS_Merged {
+			return idea.Idea{}, idea.AlreadyMergedError{Id: id}
+		}
+
+		i.Status, err = statuses.Reactivated(i.Status)
+		if err != nil {
+			return idea.Idea{}, fmt.Errorf("idea %d cannot be activated: %v", id, err)
+		}
+
+		return i, nil
+	}
+}
+
+// Asks if each new idea is the same idea as one of the existing ideas with a
+// similar name. If it is the new idea is replaced by the existing idea with
+// the new idea's body appended to it.
+func (c cmd) reuseDuplicateIds(store *idea.DirectoryStore, ideas []idea.Idea) error {
+	inEntry := make(map[uint]bool, len(ideas))
+	for _, i := range ideas {
+		inEntry[i.Id] = true
+	}
+
+	stdin := bufio.NewReader(c.Stdin)
+
+	for k, i := range ideas {
+		if i.Id != 0 {
+			continue
+		}
+
+		similar, err := store.SimilarIdeas(i.Name, idea.DefaultSimilarity)
+		if err != nil {
+			return err
+		}
+
+		// The ideas in the entry can't be duplicated
+		candidates := similar[:0]
+		for _, s := range similar {
+			if !inEntry[s.Id] {
+				candidates = append(candidates, s)
+			}
+		}
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		id, err := c.promptForDuplicate(stdin, i, candidates)
+		if err != nil {
+			return err
+		}
+
+		if id != 0 {
+			existing, err := store.IdeaById(id)
+			if err != nil {
+				return err
+			}
+
+			ideas[k], err = idea.ReuseIdea(existing, i)
+			if err != nil {
+				return err
+			}
+			inEntry[id] = true
+		}
 	}
 
 	return nil
 }
 
+// Returns the id of the idea chosen by the user or 0 to create a new idea
+func (c cmd) promptForDuplicate(stdin *bufio.Reader, newIdea idea.Idea, similar []idea.SimilarIdea) (uint, error) {
+	fmt.Fprintf(c.Stdout, "the new idea %q is similar to\n", newIdea.Name)
+	for _, s := range similar {
+		fmt.Fprintf(c.Stdout, "    [%d] %s (%s)\n", s.Id, s.Name, s.Status)
+	}
+
+	for {
+		fmt.Fprint(c.Stdout, "enter the id to reuse or leave blank to create a new idea: ")
+
+		line, err := stdin.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		answer := strings.TrimSpace(line)
+		if len(answer) == 0 {
+			return 0, nil
+		}
+
+		if id, parseErr := strconv.ParseUint(answer, 10, 0); parseErr == nil {
+			for _, s := range similar {
+				if s.Id == uint(id) {
+					return s.Id, nil
+				}
+			}
+		}
+
+		fmt.Fprintf(c.Stdout, "%s isn't one of the similar ideas\n", answer)
+
+		if err == io.EOF {
+			return 0, nil
+		}
+	}
+}
+
 func (c cmd) Summary() string {
 	return "create, edit, and save an entry to a journal"
 }
@@ -2,6 +2,10 @@ package new
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -13,6 +17,7 @@ import (
 	"github.com/ghthor/journal/git"
 	"github.com/ghthor/journal/idea"
 	initialize "github.com/ghthor/journal/init"
+	"github.com/ghthor/journal/lock"
 
 	"github.com/ghthor/gospec"
 	. "github.com/ghthor/gospec"
@@ -33,6 +38,8 @@ func (m mockEditor) Wait() error {
 }
 
 func DescribeNewCmd(c gospec.Context) {
+	ctx := context.Background()
+
 	c.Specify("the `new` command", func() {
 		// Create a temporary journal
 		journalDir, err := ioutil.TempDir("", "new_cmd_desc_")
@@ -41,9 +48,9 @@ func DescribeNewCmd(c gospec.Context) {
 			c.Assume(os.RemoveAll(journalDir), IsNil)
 		}()
 
-		commitable, err := initialize.Journal(journalDir)
+		commitable, err := initialize.Journal(ctx, journalDir)
 		c.Assume(err, IsNil)
-		c.Assume(git.Commit(commitable), IsNil)
+		c.Assume(git.Commit(ctx, commitable), IsNil)
 
 		c.Specify("will include any active ideas in the entries body while editting", func() {
 			// Create an active idea
@@ -56,9 +63,9 @@ func DescribeNewCmd(c gospec.Context) {
 				Body:   "test idea body\n",
 			}
 
-			commitable, err := store.SaveIdea(&activeIdea)
+			commitable, err := store.SaveIdea(ctx, &activeIdea)
 			c.Assume(err, IsNil)
-			c.Assume(git.Commit(commitable), IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
 
 			cmd := NewCmd(nil)
 			cmd.SetWd(journalDir)
@@ -84,7 +91,7 @@ func DescribeNewCmd(c gospec.Context) {
 
 			// Run `journal new` with mocked EditorProcess and Now functions
 			go func() {
-				c.Assume(cmd.Exec(nil), IsNil)
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
 				execCompleted <- true
 			}()
 
@@ -119,9 +126,9 @@ func DescribeNewCmd(c gospec.Context) {
 				Body:   "test idea body\n",
 			}
 
-			commitable, err := store.SaveIdea(&activeIdea)
+			commitable, err := store.SaveIdea(ctx, &activeIdea)
 			c.Assume(err, IsNil)
-			c.Assume(git.Commit(commitable), IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
 
 			cmd := NewCmd(nil)
 			cmd.SetWd(journalDir)
@@ -137,7 +144,7 @@ func DescribeNewCmd(c gospec.Context) {
 
 			cmd.EditorProcess = sedCmd
 
-			c.Expect(cmd.Exec(nil), IsNil)
+			c.Expect(cmd.Exec(ctx, nil), IsNil)
 
 			// Modify the status to reflect what happened during the edit
 			activeIdea.Status = idea.IS_Inactive
@@ -176,7 +183,7 @@ func DescribeNewCmd(c gospec.Context) {
 			}
 
 			// Run `journal new` with mocked EditorProcess and Now functions
-			c.Assume(cmd.Exec(nil), IsNil)
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
 
 			// Entry will have closing time appended
 			f, err := os.OpenFile(filepath.Join(journalDir, "entry", entryFilename), os.O_RDONLY, 0600)
@@ -212,17 +219,17 @@ func DescribeNewCmd(c gospec.Context) {
 			}
 
 			// Run `journal new` with mocked EditorProcess and Now functions
-			c.Assume(cmd.Exec(nil), IsNil)
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
 
 			// Entry will be shown in the git repository
-			c.Expect(git.IsClean(journalDir), IsNil)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
 
 			// Save test output dir for manual inspection
 			// c.Assume(exec.Command("cp", "-r", journalDir, filepath.Join("/tmp", "new_cmd_git_commit")).Run(), IsNil)
 
 			lastCommitBytes, err := git.Command(journalDir, "show", "--pretty=format:%T").Output()
 			c.Assume(err, IsNil)
-			c.Expect(string(lastCommitBytes), Equals, `2d0f3cbd0e6d8409c9bb767b7bcc09fb569eaa06
+			c.Expect(string(lastCommitBytes), Equals, `58824eb1e90d08cd11a3383e6f840772066f6d2e
 diff --git a/entry/2015-01-01-0000-UTC b/entry/2015-01-01-0000-UTC
 new file mode 100644
 index 0000000..c85666f
@@ -241,6 +248,78 @@ index 0000000..c85666f
 			c.Expect(string(hashAndTitleBytes), Equals, "Title(will be used as commit message)\n")
 		})
 
+		c.Specify("will commit the entry using the configured git backend", func() {
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "journal.json"), []byte(`{"git": {"backend": "go-git"}}`), 0600), IsNil)
+			c.Assume(git.AddFilepath(ctx, journalDir, "journal.json"), IsNil)
+			c.Assume(git.CommitWithMessage(ctx, journalDir, "use go-git"), IsNil)
+
+			cmd := NewCmd(nil)
+			cmd.SetWd(journalDir)
+
+			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			cmd.Now = func() time.Time { return openedAt }
+
+			cmd.EditorProcess = mockEditor{
+				start: func() {},
+				wait:  func() {},
+			}
+
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+			subject, err := git.Command(journalDir, "show", "-s", "--format=%s").Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(subject), Equals, "Title(will be used as commit message)\n")
+
+			committed, err := git.Command(journalDir, "show", "HEAD:entry/2015-01-01-0000-UTC").Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(committed), Equals, "Thu Jan  1 00:00:00 UTC 2015\n\n"+
+				"# Title(will be used as commit message)\n"+
+				"TODO Make this some random quote or something stupid\n\n"+
+				"Thu Jan  1 00:00:00 UTC 2015\n")
+		})
+
+		c.Specify("will date the commit with when the entry was written", func() {
+			cmd := NewCmd(nil)
+			cmd.SetWd(journalDir)
+
+			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			closedAt := openedAt.Add(30 * time.Minute)
+
+			now := []time.Time{openedAt, closedAt}
+			cmd.Now = func() time.Time {
+				t := now[0]
+				now = now[1:]
+				return t
+			}
+
+			cmd.EditorProcess = mockEditor{
+				start: func() {},
+				wait:  func() {},
+			}
+
+			c.Specify("using the opened at time for the author date", func() {
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
+
+				dates, err := git.Command(journalDir, "show", "-s", "--format=%ai|%ci").Output()
+				c.Assume(err, IsNil)
+				c.Expect(strings.HasPrefix(string(dates), "2015-01-01 00:00:00 +0000|"), IsTrue)
+				c.Expect(strings.Contains(string(dates), "|2015-"), IsFalse)
+			})
+
+			c.Specify("and the closed at time for the committer date if configured", func() {
+				c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "journal.json"), []byte(`{"git": {"dates": "all"}}`), 0600), IsNil)
+				c.Assume(git.AddFilepath(ctx, journalDir, "journal.json"), IsNil)
+				c.Assume(git.CommitWithMessage(ctx, journalDir, "date commits"), IsNil)
+
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
+
+				dates, err := git.Command(journalDir, "show", "-s", "--format=%ai|%ci").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(dates), Equals, "2015-01-01 00:00:00 +0000|2015-01-01 00:30:00 +0000\n")
+			})
+		})
+
 		c.Specify("will commit any modifications to the idea store", func() {
 			cmd := NewCmd(nil)
 			cmd.SetWd(journalDir)
@@ -255,9 +334,9 @@ index 0000000..c85666f
 				Body:   "test idea body\n",
 			}
 
-			commitable, err := store.SaveIdea(&activeIdea)
+			commitable, err := store.SaveIdea(ctx, &activeIdea)
 			c.Assume(err, IsNil)
-			c.Assume(git.Commit(commitable), IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
 
 			// Mock time to control the filename and openedAt/closedAt times stored in the entry
 			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
@@ -271,16 +350,16 @@ index 0000000..c85666f
 			cmd.EditorProcess = editCmd
 
 			// Run `journal new` with mocked EditorProcess and Now functions
-			c.Assume(cmd.Exec(nil), IsNil)
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
 
-			c.Expect(git.IsClean(journalDir), IsNil)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
 
 			// Save test output dir for manual inspection
 			// c.Assume(exec.Command("cp", "-r", journalDir, filepath.Join("/tmp", "new_cmd_git_commit")).Run(), IsNil)
 
 			lastCommitBytes, err := git.Command(journalDir, "show", "--pretty=format:%T", "HEAD^").Output()
 			c.Assume(err, IsNil)
-			c.Expect(string(lastCommitBytes), Equals, `d6551c5250432aaa5244aa7767ae672cb316c1bb
+			c.Expect(string(lastCommitBytes), Equals, `d4f58ec295cd984e08500f733befde71763f7161
 diff --git a/idea/1 b/idea/1
 index 83f5e84..0b22af3 100644
 --- a/idea/1
@@ -294,6 +373,484 @@ index 83f5e84..0b22af3 100644
 			c.Assume(err, IsNil)
 			c.Expect(string(hashAndTitleBytes), Equals, "idea - updated - 1\n")
 
+			// The commits of the entry and the idea are related by their trailers
+			log, err := git.Log(ctx, journalDir, git.LogOptions{Range: "HEAD", Max: 2})
+			c.Assume(err, IsNil)
+			defer log.Close()
+
+			c.Assume(log.Scan(), IsTrue)
+			c.Expect(log.Entry().Trailers(git.IdeaUpdatedTrailer), ContainsExactly, []string{"1"})
+			c.Expect(log.Entry().Trailers(git.EntryTrailer), ContainsExactly, []string{})
+
+			c.Assume(log.Scan(), IsTrue)
+			c.Expect(log.Entry().Trailers(git.EntryTrailer), ContainsExactly, []string{"entry/" + entryFilename})
+		})
+
+		c.Specify("will keep the active ideas in the order they are written in the entry", func() {
+			cmd := NewCmd(nil)
+			cmd.SetWd(journalDir)
+
+			store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+			c.Assume(err, IsNil)
+
+			for _, name := range []string{"first", "second"} {
+				commitable, err := store.SaveIdea(ctx, &idea.Idea{
+					Status: idea.IS_Active,
+					Name:   name,
+					Body:   name + " body\n",
+				})
+				c.Assume(err, IsNil)
+				c.Assume(git.Commit(ctx, commitable), IsNil)
+			}
+
+			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			cmd.Now = func() time.Time { return openedAt }
+
+			entryPath := filepath.Join(journalDir, "entry", openedAt.Format(entry.FilenameLayout))
+
+			// Swap the order of the idea blocks
+			cmd.EditorProcess = mockEditor{
+				start: func() {},
+				wait: func() {
+					data, err := ioutil.ReadFile(entryPath)
+					c.Assume(err, IsNil)
+
+					first := "## [active] [1] first\nfirst body\n"
+					second := "## [active] [2] second\nsecond body\n"
+					c.Assume(strings.Contains(string(data), first+"\n"+second), IsTrue)
+
+					data = []byte(strings.Replace(string(data), first+"\n"+second, second+"\n"+first, 1))
+					c.Assume(ioutil.WriteFile(entryPath, data, 0600), IsNil)
+				},
+			}
+
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
+			c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+			active, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "active"))
+			c.Assume(err, IsNil)
+			c.Expect(string(active), Equals, "2\n1\n")
+
+			subject, err := git.Command(journalDir, "show", "-s", "--format=%s", "HEAD^").Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(subject), Equals, "idea - reordered active\n")
+		})
+
+		c.Specify("with modified ideas", func() {
+			cmd := NewCmd(nil)
+			cmd.SetWd(journalDir)
+
+			store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+			c.Assume(err, IsNil)
+
+			for _, name := range []string{"first", "second"} {
+				commitable, err := store.SaveIdea(ctx, &idea.Idea{
+					Status: idea.IS_Active,
+					Name:   name,
+					Body:   name + " body\n",
+				})
+				c.Assume(err, IsNil)
+				c.Assume(git.Commit(ctx, commitable), IsNil)
+			}
+
+			startedAt, err := git.Command(journalDir, "rev-parse", "HEAD").Output()
+			c.Assume(err, IsNil)
+
+			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			cmd.Now = func() time.Time { return openedAt }
+
+			entryPath := filepath.Join(journalDir, "entry", openedAt.Format(entry.FilenameLayout))
+
+			editEntry := func(old, new string) {
+				cmd.EditorProcess = mockEditor{
+					start: func() {},
+					wait: func() {
+						data, err := ioutil.ReadFile(entryPath)
+						c.Assume(err, IsNil)
+						c.Assume(strings.Contains(string(data), old), IsTrue)
+
+						data = []byte(strings.Replace(string(data), old, new, 1))
+						c.Assume(ioutil.WriteFile(entryPath, data, 0600), IsNil)
+					},
+				}
+			}
+
+			c.Specify("will commit the ideas and the entry together if configured", func() {
+				c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "journal.json"), []byte(`{"git": {"commits": "combined"}}`), 0600), IsNil)
+				c.Assume(git.AddFilepath(ctx, journalDir, "journal.json"), IsNil)
+				c.Assume(git.CommitWithMessage(ctx, journalDir, "combine commits"), IsNil)
+
+				editEntry("[1] first\n", "[1] first edited\n")
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+				msg, err := git.Command(journalDir, "show", "-s", "--format=%B").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(msg), Equals, "Title(will be used as commit message)\n\nidea - updated - 1\n\nIdea-Updated: 1\nEntry: entry/2015-01-01-0000-UTC\n\n")
+
+				files, err := git.Command(journalDir, "show", "--name-only", "--format=").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(files), Equals, "entry/2015-01-01-0000-UTC\nidea/1\n")
+
+				subject, err := git.Command(journalDir, "show", "-s", "--format=%s", "HEAD^").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(subject), Equals, "combine commits\n")
+			})
+
+			c.Specify("will commit the ideas and the entry together if requested", func() {
+				editEntry("[1] first\n", "[1] first edited\n")
+				c.Assume(cmd.Exec(ctx, []string{"-commits", "combined"}), IsNil)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+				subject, err := git.Command(journalDir, "show", "-s", "--format=%s", "HEAD^").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(subject), Equals, "idea - created - 2\n")
+			})
+
+			c.Specify("will not commit anything", func() {
+				stdout := bytes.NewBuffer(nil)
+				cmd.Stdout = stdout
+
+				savedPath := filepath.Join(journalDir, ".git", UncommittedDir, openedAt.Format(entry.FilenameLayout))
+
+				expectNothingCommitted := func() {
+					head, err := git.Command(journalDir, "rev-parse", "HEAD").Output()
+					c.Assume(err, IsNil)
+					c.Expect(string(head), Equals, string(startedAt))
+
+					status, err := git.Command(journalDir, "status", "--porcelain", "--untracked-files=all").Output()
+					c.Assume(err, IsNil)
+					c.Expect(string(status), Equals, "")
+
+					data, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "1"))
+					c.Assume(err, IsNil)
+					c.Expect(string(data), Equals, "## [active] [1] first\nfirst body\n")
+
+					c.Expect(stdout.String(), Equals, "the entry wasn't committed, what was written is saved in "+savedPath+"\n")
+				}
+
+				c.Specify("if the entry doesn't have a title", func() {
+					editEntry("# Title(will be used as commit message)\n", "")
+
+					c.Expect(cmd.Exec(ctx, nil), Equals, entry.ErrNoCommitMsg)
+					expectNothingCommitted()
+
+					data, err := ioutil.ReadFile(savedPath)
+					c.Assume(err, IsNil)
+					c.Expect(strings.Contains(string(data), "## [active] [1] first\n"), IsTrue)
+				})
+
+				c.Specify("if it's interrupted", func() {
+					interrupted := errors.New("interrupted")
+					canceled, cancel := context.WithCancelCause(ctx)
+
+					editEntry("[1] first\n", "[1] first edited\n")
+					edit := cmd.EditorProcess.(mockEditor)
+					cmd.EditorProcess = mockEditor{
+						start: edit.start,
+						wait: func() {
+							edit.wait()
+							cancel(interrupted)
+						},
+					}
+
+					c.Expect(cmd.Exec(canceled, nil), Equals, interrupted)
+					expectNothingCommitted()
+
+					_, err := os.Stat(filepath.Join(journalDir, ".git", "index.lock"))
+					c.Expect(os.IsNotExist(err), IsTrue)
+
+					data, err := ioutil.ReadFile(savedPath)
+					c.Assume(err, IsNil)
+					c.Expect(strings.Contains(string(data), "## [active] [1] first edited\n"), IsTrue)
+				})
+
+				c.Specify("if an idea fails to save after another idea was committed", func() {
+					cmd.EditorProcess = mockEditor{
+						start: func() {},
+						wait: func() {
+							data, err := ioutil.ReadFile(entryPath)
+							c.Assume(err, IsNil)
+
+							data = []byte(strings.Replace(string(data), "[1] first\n", "[1] first edited\n", 1))
+							data = []byte(strings.Replace(string(data), "[active] [2] second\n", "[unknown] [2] second\n", 1))
+							c.Assume(ioutil.WriteFile(entryPath, data, 0600), IsNil)
+						},
+					}
+
+					err := cmd.Exec(ctx, nil)
+					c.Expect(idea.IsUnknownStatusError(err), IsTrue)
+					expectNothingCommitted()
+
+					// What was written in the entry is kept
+					data, err := ioutil.ReadFile(savedPath)
+					c.Assume(err, IsNil)
+					c.Expect(strings.Contains(string(data), "## [active] [1] first edited\n"), IsTrue)
+					c.Expect(strings.Contains(string(data), "## [unknown] [2] second\n"), IsTrue)
+				})
+
+				c.Specify("if an idea fails to save after a new idea was written in combined mode", func() {
+					cmd.EditorProcess = mockEditor{
+						start: func() {},
+						wait: func() {
+							data, err := ioutil.ReadFile(entryPath)
+							c.Assume(err, IsNil)
+
+							data = []byte(strings.Replace(string(data), "[active] [2] second\n", "[unknown] [2] second\n", 1))
+							data = append(data, "\n## [active] a new idea\nnew idea body\n"...)
+							c.Assume(ioutil.WriteFile(entryPath, data, 0600), IsNil)
+						},
+					}
+
+					err := cmd.Exec(ctx, []string{"-commits", "combined"})
+					c.Expect(idea.IsUnknownStatusError(err), IsTrue)
+					expectNothingCommitted()
+
+					_, err = os.Stat(filepath.Join(journalDir, "idea", "3"))
+					c.Expect(os.IsNotExist(err), IsTrue)
+
+					data, err := ioutil.ReadFile(savedPath)
+					c.Assume(err, IsNil)
+					c.Expect(strings.Contains(string(data), "## [active] a new idea\n"), IsTrue)
+				})
+			})
+		})
+
+		c.Specify("will bring inactive ideas back into the entry", func() {
+			store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+			c.Assume(err, IsNil)
+
+			for _, i := range []idea.Idea{
+				{Status: idea.IS_Inactive, Name: "inactive idea", Body: "inactive idea body\n"},
+				{Status: idea.IS_Completed, Name: "completed idea", Body: "completed idea body\n"},
+			} {
+				commitable, err := store.SaveIdea(ctx, &i)
+				c.Assume(err, IsNil)
+				c.Assume(git.Commit(ctx, commitable), IsNil)
+			}
+
+			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			entryPath := filepath.Join(journalDir, "entry", openedAt.Format(entry.FilenameLayout))
+
+			cmd := NewCmd(nil)
+			cmd.SetWd(journalDir)
+			cmd.Now = func() time.Time { return openedAt }
+
+			expectActive := func(expected string) {
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+				active, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "active"))
+				c.Assume(err, IsNil)
+				c.Expect(string(active), Equals, expected)
+			}
+
+			c.Specify("that are requested with --idea", func() {
+				var edited string
+				cmd.EditorProcess = mockEditor{
+					start: func() {},
+					wait: func() {
+						data, err := ioutil.ReadFile(entryPath)
+						c.Assume(err, IsNil)
+						edited = string(data)
+					},
+				}
+
+				c.Assume(cmd.Exec(ctx, []string{"--idea", "2", "--idea", "1"}), IsNil)
+				c.Expect(strings.Contains(edited, "## [active] [2] completed idea\ncompleted idea body\n\n## [active] [1] inactive idea\n"), IsTrue)
+				expectActive("2\n1\n")
+			})
+
+			c.Specify("that are included with a directive in the entry", func() {
+				cmd.EditorProcess = mockEditor{
+					start: func() {},
+					wait: func() {
+						data, err := ioutil.ReadFile(entryPath)
+						c.Assume(err, IsNil)
+
+						data = []byte(strings.Replace(string(data), "\n# ", "\n{{idea 1}}\n# ", 1))
+						c.Assume(ioutil.WriteFile(entryPath, data, 0600), IsNil)
+					},
+				}
+
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
+				expectActive("1\n")
+
+				actual, err := store.IdeaById(1)
+				c.Assume(err, IsNil)
+				c.Expect(actual.Status, Equals, idea.IS_Active)
+
+				data, err := ioutil.ReadFile(entryPath)
+				c.Assume(err, IsNil)
+				c.Expect(strings.Contains(string(data), "{{idea"), IsFalse)
+			})
+
+			c.Specify("unless the idea doesn't exist", func() {
+				cmd.EditorProcess = mockEditor{start: func() {}, wait: func() {}}
+				c.Expect(fmt.Sprint(cmd.Exec(ctx, []string{"--idea", "3"})), Equals, "idea 3 doesn't exist")
+			})
+		})
+
+		c.Specify("will offer to reuse the id of an idea with a similar name", func() {
+			cmd := NewCmd(nil)
+			cmd.SetWd(journalDir)
+
+			store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+			c.Assume(err, IsNil)
+
+			existing := idea.Idea{
+				Status: idea.IS_Inactive,
+				Name:   "Write the report",
+				Body:   "notes on the report\n- [ ] draft it\n",
+			}
+			commitable, err := store.SaveIdea(ctx, &existing)
+			c.Assume(err, IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
+
+			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			cmd.Now = func() time.Time { return openedAt }
+
+			entryPath := filepath.Join(journalDir, "entry", openedAt.Format(entry.FilenameLayout))
+
+			// Write a new idea that is a duplicate of the existing idea
+			cmd.EditorProcess = mockEditor{
+				start: func() {},
+				wait: func() {
+					f, err := os.OpenFile(entryPath, os.O_APPEND|os.O_WRONLY, 0600)
+					c.Assume(err, IsNil)
+					defer f.Close()
+
+					_, err = f.WriteString("\n## [active] write report\nmore notes\n- [ ] draft it\n- [ ] review it\n")
+					c.Assume(err, IsNil)
+				},
+			}
+
+			stdout := bytes.NewBuffer(nil)
+			cmd.Stdout = stdout
+
+			c.Specify("and add the new idea to the existing idea if the id is entered", func() {
+				cmd.Stdin = strings.NewReader("1\n")
+
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+				c.Expect(stdout.String(), Equals, `the new idea "write report" is similar to
+    [1] Write the report (inactive)
+enter the id to reuse or leave blank to create a new idea: `)
+
+				actual, err := store.IdeaById(1)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, idea.Idea{
+					Status: idea.IS_Active,
+					Id:     1,
+					Name:   "Write the report",
+					Body:   "notes on the report\n- [ ] draft it\n\nmore notes\n- [ ] review it\n",
+				})
+
+				nextid, err := ioutil.ReadFile(filepath.Join(journalDir, "idea", "nextid"))
+				c.Assume(err, IsNil)
+				c.Expect(string(nextid), Equals, "2\n")
+			})
+
+			c.Specify("and create a new idea if nothing is entered", func() {
+				cmd.Stdin = strings.NewReader("\n")
+
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+				actual, err := store.IdeaById(2)
+				c.Assume(err, IsNil)
+				c.Expect(actual.Name, Equals, "write report")
+
+				actual, err = store.IdeaById(1)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, existing)
+			})
+		})
+
+		c.Specify("will merge the changes made to an idea while the entry was open", func() {
+			cmd := NewCmd(nil)
+			cmd.SetWd(journalDir)
+
+			store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+			c.Assume(err, IsNil)
+
+			activeIdea := idea.Idea{
+				Status: idea.IS_Active,
+				Name:   "test idea",
+				Body:   "line 1\nline 2\nline 3\n",
+			}
+
+			commitable, err := store.SaveIdea(ctx, &activeIdea)
+			c.Assume(err, IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
+
+			openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+			cmd.Now = func() time.Time { return openedAt }
+
+			entryPath := filepath.Join(journalDir, "entry", openedAt.Format(entry.FilenameLayout))
+
+			// Modifies the idea's file and then edits the entry
+			editWith := func(fileBody, entryLine string) {
+				cmd.EditorProcess = mockEditor{
+					start: func() {},
+					wait: func() {
+						modified := activeIdea
+						modified.Body = fileBody
+
+						commitable, err := store.SaveIdea(ctx, &modified)
+						c.Assume(err, IsNil)
+						c.Assume(git.Commit(ctx, commitable), IsNil)
+
+						data, err := ioutil.ReadFile(entryPath)
+						c.Assume(err, IsNil)
+
+						data = []byte(strings.Replace(string(data), "line 1\n", entryLine, 1))
+						c.Assume(ioutil.WriteFile(entryPath, data, 0600), IsNil)
+					},
+				}
+			}
+
+			c.Specify("if the changes don't conflict", func() {
+				editWith("line 1\nline 2\nline 3 modified\n", "line 1 edited\n")
+
+				c.Assume(cmd.Exec(ctx, nil), IsNil)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+
+				actual, err := store.IdeaById(activeIdea.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual.Body, Equals, "line 1 edited\nline 2\nline 3 modified\n")
+			})
+
+			c.Specify("and will write the conflicts into the entry", func() {
+				editWith("line 1 modified\nline 2\nline 3\n", "line 1 edited\n")
+
+				err := cmd.Exec(ctx, nil)
+				conflict, isConflict := err.(MergeConflictError)
+				c.Assume(isConflict, IsTrue)
+				c.Expect(conflict.Filename, Equals, entryPath)
+				c.Expect(conflict.Ids, ContainsExactly, []uint{1})
+
+				data, err := ioutil.ReadFile(entryPath)
+				c.Assume(err, IsNil)
+				c.Expect(strings.HasSuffix(string(data), `
+## [active] [1] test idea
+<<<<<<< entry
+line 1 edited
+=======
+line 1 modified
+>>>>>>> idea/1
+line 2
+line 3
+`), IsTrue)
+
+				// Nothing was committed
+				subject, err := git.Command(journalDir, "show", "-s", "--format=%s").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(subject), Equals, "idea - updated - 1\n")
+
+				actual, err := store.IdeaById(activeIdea.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual.Body, Equals, "line 1 modified\nline 2\nline 3\n")
+			})
 		})
 
 		c.Specify("will fail", func() {
@@ -303,7 +860,7 @@ index 83f5e84..0b22af3 100644
 			c.Specify("if the journal directory has a dirty git repository", func() {
 				// Dirty the test journal
 				c.Assume(exec.Command("touch", filepath.Join(journalDir, "makedirty")).Run(), IsNil)
-				c.Assume(git.IsClean(journalDir), Not(IsNil))
+				c.Assume(git.IsClean(ctx, journalDir), Not(IsNil))
 
 				// Mocked editor that does nothing
 				cmd.EditorProcess = mockEditor{
@@ -312,32 +869,30 @@ index 83f5e84..0b22af3 100644
 				}
 
 				// Will fail with an error
-				c.Expect(cmd.Exec(nil), Equals, ErrGitIsDirty)
+				c.Expect(cmd.Exec(ctx, nil), Equals, ErrGitIsDirty)
 			})
-		})
-	})
 
-	c.Specify("the environment editor", func() {
-		c.Specify("will be vim", func() {
-			cmd, err := newEnvEditor("vim", "entryFilename")
-			c.Assume(err, IsNil)
+			c.Specify("if the journal is locked by another process", func() {
+				// The parent process is guaranteed to be alive
+				lockFile := filepath.Join(journalDir, ".git", lock.Filename)
+				c.Assume(ioutil.WriteFile(lockFile, []byte(fmt.Sprintln(os.Getppid())), 0600), IsNil)
 
-			c.Expect(filepath.Base(cmd.Args[0]), Equals, "vim")
-			c.Expect(strings.Join(cmd.Args[1:], " "), Equals, "+set spell entryFilename")
-		})
-
-		c.Specify("will be emacs", func() {
-			cmd, err := newEnvEditor("emacs", "entryFilename")
-			c.Assume(err, IsNil)
+				openedAt := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+				cmd.Now = func() time.Time { return openedAt }
 
-			c.Expect(filepath.Base(cmd.Args[0]), Equals, "emacs")
-			c.Expect(strings.Join(cmd.Args[1:], " "), Equals, "entryFilename")
+				cmd.EditorProcess = mockEditor{
+					start: func() {},
+					wait:  func() {},
+				}
 
-			cmd, err = newEnvEditor("emacs -nw", "entryFilename")
-			c.Assume(err, IsNil)
+				err := cmd.Exec(ctx, nil)
+				c.Expect(lock.IsLockedError(err), IsTrue)
+				c.Expect(err.Error(), Equals, fmt.Sprintf("journal is locked by pid %d", os.Getppid()))
 
-			c.Expect(filepath.Base(cmd.Args[0]), Equals, "emacs")
-			c.Expect(strings.Join(cmd.Args[1:], " "), Equals, "-nw entryFilename")
+				// The entry will not have been created
+				_, err = os.Stat(filepath.Join(journalDir, "entry", openedAt.Format(entry.FilenameLayout)))
+				c.Expect(os.IsNotExist(err), IsTrue)
+			})
 		})
 	})
 }
@@ -4,7 +4,12 @@ import (
 	// init is a reserved keyword
 	initc "github.com/ghthor/journal/cmd_verbs/init"
 
+	"github.com/ghthor/journal/cmd_verbs/agenda"
 	"github.com/ghthor/journal/cmd_verbs/fix"
+	"github.com/ghthor/journal/cmd_verbs/idea"
+	mergedriver "github.com/ghthor/journal/cmd_verbs/merge-driver"
+	"github.com/ghthor/journal/cmd_verbs/sync"
+	"github.com/ghthor/journal/cmd_verbs/verify"
 
 	// new is a reserved keyword
 	newc "github.com/ghthor/journal/cmd_verbs/new"
@@ -14,4 +19,9 @@ func init() {
 	c.RegisterAsPkg(initc.Cmd)
 	c.RegisterAsPkg(fix.Cmd)
 	c.RegisterAsPkg(newc.Cmd)
+	c.RegisterAsPkg(idea.Cmd)
+	c.RegisterAsPkg(agenda.Cmd)
+	c.RegisterAsPkg(verify.Cmd)
+	c.RegisterAsPkg(sync.Cmd)
+	c.RegisterAsPkg(mergedriver.Cmd)
 }
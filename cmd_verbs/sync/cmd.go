@@ -0,0 +1,277 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghthor/journal/config"
+	"github.com/ghthor/journal/git"
+	initialize "github.com/ghthor/journal/init"
+	"github.com/ghthor/journal/lock"
+)
+
+var Cmd = NewCmd(nil)
+
+type cmd struct {
+	flagSet *flag.FlagSet
+
+	wd string // working directory
+
+	// Rebase onto the remotes instead of merging
+	rebase *bool
+
+	// Fetching or pushing a remote gives up after the timeout
+	timeout *time.Duration
+
+	// Output for the progress of the sync
+	Stdout io.Writer
+}
+
+var (
+	ErrGitIsDirty = errors.New("git is dirty")
+	ErrNoRemotes  = errors.New("the journal doesn't have any remotes to sync with")
+)
+
+// Returned when the changes from a remote conflict with the journal.
+// The conflicts describe the entries and ideas that were changed on both sides.
+type ConflictError struct {
+	Remote    string
+	Conflicts []string
+
+	// True if the conflicts were left in the working tree to be resolved
+	Merging bool
+}
+
+func (e ConflictError) Error() string {
+	msg := fmt.Sprintf("syncing with %s conflicted:\n    %s\n", e.Remote, strings.Join(e.Conflicts, "\n    "))
+
+	if !e.Merging {
+		return msg + "the rebase was aborted, sync again without -rebase to resolve the conflicts in a merge"
+	}
+
+	msg += "resolve the conflicts, commit the merge and sync again"
+
+	for _, conflict := range e.Conflicts {
+		if strings.HasPrefix(conflict, "idea ") {
+			return msg + "\nideas created with the same id on both sides can be resolved with `journal idea renumber`"
+		}
+	}
+
+	return msg
+}
+
+func IsConflictError(err error) bool {
+	_, ok := err.(ConflictError)
+	return ok
+}
+
+func NewCmd(flagSet *flag.FlagSet) *cmd {
+	if flagSet == nil {
+		flagSet = flag.NewFlagSet("sync", flag.ExitOnError)
+	}
+
+	c := &cmd{
+		flagSet: flagSet,
+		Stdout:  os.Stdout,
+	}
+
+	c.rebase = c.flagSet.Bool("rebase", false, "rebase onto the remotes instead of merging, overrides journal.json")
+	c.timeout = c.flagSet.Duration("timeout", 0, "give up fetching or pushing a remote after the duration, 0 waits forever")
+
+	return c
+}
+
+func (c *cmd) SetWd(directory string) {
+	c.wd = directory
+}
+
+func (c *cmd) Exec(ctx context.Context, args []string) error {
+	c.flagSet.Parse(args)
+
+	a := c.flagSet.Args()
+
+	var path string
+
+	switch len(a) {
+	case 0:
+		path = c.wd
+	case 1:
+		path = a[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.wd, path)
+		}
+
+	default:
+		return errors.New("too many arguments")
+	}
+
+	l, err := lock.Journal(path)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if git.IsClean(ctx, path) != nil {
+		return ErrGitIsDirty
+	}
+
+	// The drivers aren't cloned with the repository
+	err = initialize.RegisterMergeDrivers(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	branch, err := git.CurrentBranch(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	remotes := cfg.Git.Remotes
+	if len(remotes) == 0 {
+		remotes, err = git.Remotes(ctx, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(remotes) == 0 {
+		return ErrNoRemotes
+	}
+
+	rebase := *c.rebase || cfg.Git.Sync == config.RebaseSync
+
+	for _, remote := range remotes {
+		err := c.withTimeout(ctx, "fetching "+remote, func(ctx context.Context) error {
+			return git.Fetch(ctx, path, remote)
+		})
+		if err != nil {
+			return err
+		}
+
+		// The branch will be created by pushing it
+		upstream := remote + "/" + branch
+		if !git.HasRev(ctx, path, upstream) {
+			continue
+		}
+
+		if rebase {
+			err = git.Rebase(ctx, path, upstream)
+		} else {
+			err = git.Merge(ctx, path, upstream)
+		}
+
+		if err != nil {
+			return conflictErrorFrom(ctx, path, remote, rebase, err)
+		}
+
+		fmt.Fprintf(c.Stdout, "pulled %s\n", upstream)
+	}
+
+	for _, remote := range remotes {
+		err := c.withTimeout(ctx, "pushing to "+remote, func(ctx context.Context) error {
+			return git.Push(ctx, path, remote, branch)
+		})
+		if git.IsPushRejected(err) {
+			return fmt.Errorf("%s was changed while syncing, sync again to pull the changes\n%v", remote, err)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.Stdout, "pushed %s to %s\n", branch, remote)
+	}
+
+	return nil
+}
+
+// Executes an operation on a remote that is
+// interrupted if it hasn't completed by the timeout
+func (c *cmd) withTimeout(ctx context.Context, operation string, fn func(context.Context) error) error {
+	if *c.timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *c.timeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %v", operation, *c.timeout)
+	}
+
+	return err
+}
+
+// Describes the conflicts left by a merge or rebase that failed.
+// If there aren't any conflicts the error is returned.
+func conflictErrorFrom(ctx context.Context, journalDir, remote string, rebase bool, err error) error {
+	unmerged, unmergedErr := git.UnmergedFiles(ctx, journalDir)
+	if unmergedErr != nil || len(unmerged) == 0 {
+		return err
+	}
+
+	conflicts := make([]string, 0, len(unmerged))
+	for _, path := range unmerged {
+		conflicts = append(conflicts, describe(journalDir, path))
+	}
+
+	if rebase {
+		abortErr := git.AbortRebase(ctx, journalDir)
+		if abortErr != nil {
+			return abortErr
+		}
+	}
+
+	return ConflictError{remote, conflicts, !rebase}
+}
+
+// Describes a file in the journal in journal terms
+func describe(journalDir, path string) string {
+	// The repository's root may be reached through a symlink
+	if dir, err := filepath.EvalSymlinks(journalDir); err == nil {
+		journalDir = dir
+	}
+
+	rel, err := filepath.Rel(journalDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	dir, name := filepath.Split(filepath.ToSlash(rel))
+	switch dir {
+	case "entry/":
+		return "entry " + name
+
+	case "idea/":
+		switch name {
+		case "active":
+			return "the order of the active ideas"
+		case "nextid":
+			return "the next idea id"
+		}
+		return "idea " + name
+	}
+
+	if rel == config.Filename {
+		return "the journal configuration"
+	}
+
+	return rel
+}
+
+func (c cmd) Summary() string {
+	return "pull, merge and push the journal's remotes"
+}
@@ -0,0 +1,184 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/idea"
+	initialize "github.com/ghthor/journal/init"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeSyncCmd(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("the `sync` command", func() {
+		d, err := ioutil.TempDir("", "sync_cmd_desc_")
+		c.Assume(err, IsNil)
+		defer func() {
+			c.Assume(os.RemoveAll(d), IsNil)
+		}()
+
+		// A bare repository is the remote shared by two clones of a journal
+		remoteDir := filepath.Join(d, "remote.git")
+		c.Assume(git.Command(d, "init", "-q", "--bare", remoteDir).Run(), IsNil)
+
+		journalDir := filepath.Join(d, "journal")
+		c.Assume(os.Mkdir(journalDir, 0700), IsNil)
+
+		commitable, err := initialize.Journal(ctx, journalDir)
+		c.Assume(err, IsNil)
+		c.Assume(git.Commit(ctx, commitable), IsNil)
+
+		branch, err := git.CurrentBranch(ctx, journalDir)
+		c.Assume(err, IsNil)
+
+		c.Assume(git.Command(journalDir, "remote", "add", "origin", remoteDir).Run(), IsNil)
+		c.Assume(git.Push(ctx, journalDir, "origin", branch), IsNil)
+
+		otherDir := filepath.Join(d, "other")
+		c.Assume(git.Command(d, "clone", "-q", remoteDir, otherDir).Run(), IsNil)
+
+		saveIdea := func(journalDir, name string) {
+			store, err := idea.NewDirectoryStore(filepath.Join(journalDir, "idea"))
+			c.Assume(err, IsNil)
+
+			commitable, err := store.SaveIdea(ctx, &idea.Idea{
+				Status: idea.IS_Active,
+				Name:   name,
+				Body:   name + " body\n",
+			})
+			c.Assume(err, IsNil)
+			c.Assume(git.Commit(ctx, commitable), IsNil)
+		}
+
+		commitEntry := func(journalDir, filename string) {
+			c.Assume(os.MkdirAll(filepath.Join(journalDir, "entry"), 0700), IsNil)
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "entry", filename), []byte("# "+filename+"\n"), 0600), IsNil)
+			c.Assume(git.AddFilepath(ctx, journalDir, "entry"), IsNil)
+			c.Assume(git.CommitWithMessage(ctx, journalDir, filename), IsNil)
+		}
+
+		subjects := func(dir, rev string) string {
+			o, err := git.Command(dir, "log", "--format=%s", rev).Output()
+			c.Assume(err, IsNil)
+			return string(o)
+		}
+
+		output := bytes.NewBuffer(nil)
+
+		cmd := NewCmd(nil)
+		cmd.SetWd(journalDir)
+		cmd.Stdout = output
+
+		c.Specify("will pull the changes from the remote and push the journal's changes", func() {
+			commitEntry(otherDir, "2015-01-01-0000-UTC")
+			c.Assume(git.Push(ctx, otherDir, "origin", branch), IsNil)
+
+			commitEntry(journalDir, "2015-01-02-0000-UTC")
+
+			c.Assume(cmd.Exec(ctx, nil), IsNil)
+			c.Expect(output.String(), Equals, "pulled origin/"+branch+"\npushed "+branch+" to origin\n")
+
+			_, err := os.Stat(filepath.Join(journalDir, "entry", "2015-01-01-0000-UTC"))
+			c.Expect(err, IsNil)
+
+			head, err := git.Command(journalDir, "rev-parse", "HEAD").Output()
+			c.Assume(err, IsNil)
+			remoteHead, err := git.Command(remoteDir, "rev-parse", branch).Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(remoteHead), Equals, string(head))
+
+			c.Specify("and will register the merge drivers in a clone", func() {
+				otherCmd := NewCmd(nil)
+				otherCmd.SetWd(otherDir)
+				otherCmd.Stdout = ioutil.Discard
+
+				c.Assume(git.Command(otherDir, "config", "merge.journal-active.driver").Run(), Not(IsNil))
+				c.Assume(otherCmd.Exec(ctx, nil), IsNil)
+
+				for _, d := range initialize.MergeDrivers {
+					driver, err := git.Command(otherDir, "config", "merge."+d.Name+".driver").Output()
+					c.Assume(err, IsNil)
+					c.Expect(string(driver), Equals, d.Command+"\n")
+				}
+			})
+
+			c.Specify("and will rebase onto the remote if requested", func() {
+				c.Assume(git.Command(otherDir, "pull", "-q", "--no-rebase", "origin", branch).Run(), IsNil)
+				commitEntry(otherDir, "2015-01-03-0000-UTC")
+				c.Assume(git.Push(ctx, otherDir, "origin", branch), IsNil)
+
+				commitEntry(journalDir, "2015-01-04-0000-UTC")
+
+				c.Assume(cmd.Exec(ctx, []string{"-rebase"}), IsNil)
+
+				latest, err := git.Command(journalDir, "log", "-2", "--format=%s").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(latest), Equals, "2015-01-04-0000-UTC\n2015-01-03-0000-UTC\n")
+			})
+		})
+
+		c.Specify("will report the entries and ideas that conflict", func() {
+			saveIdea(otherDir, "their idea")
+			c.Assume(git.Push(ctx, otherDir, "origin", branch), IsNil)
+
+			saveIdea(journalDir, "our idea")
+			before := subjects(journalDir, "HEAD")
+
+			head, err := git.Command(journalDir, "rev-parse", "HEAD").Output()
+			c.Assume(err, IsNil)
+
+			c.Specify("and leave the merge to be resolved", func() {
+				err := cmd.Exec(ctx, nil)
+				c.Assume(IsConflictError(err), IsTrue)
+				c.Expect(err.(ConflictError).Merging, IsTrue)
+				c.Expect(err.Error(), Equals, "syncing with origin conflicted:\n"+
+					"    idea 1\n"+
+					"resolve the conflicts, commit the merge and sync again\n"+
+					"ideas created with the same id on both sides can be resolved with `journal idea renumber`")
+
+				c.Expect(git.HasRev(ctx, journalDir, "MERGE_HEAD"), IsTrue)
+			})
+
+			c.Specify("and abort a rebase", func() {
+				err := cmd.Exec(ctx, []string{"-rebase"})
+				c.Assume(IsConflictError(err), IsTrue)
+				c.Expect(err.(ConflictError).Merging, IsFalse)
+				c.Expect(git.IsClean(ctx, journalDir), IsNil)
+				c.Expect(subjects(journalDir, "HEAD"), Equals, before)
+			})
+
+			// Nothing was pushed
+			c.Expect(git.HasRev(ctx, remoteDir, string(bytes.TrimSpace(head))), IsFalse)
+		})
+
+		c.Specify("will fail", func() {
+			c.Specify("if the journal has a dirty git repository", func() {
+				c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "makedirty"), nil, 0600), IsNil)
+				c.Expect(cmd.Exec(ctx, nil), Equals, ErrGitIsDirty)
+			})
+
+			c.Specify("if a remote doesn't respond before the timeout", func() {
+				// The remote never responds to git
+				c.Assume(git.SetConfig(ctx, journalDir, "protocol.ext.allow", "always"), IsNil)
+				c.Assume(git.SetConfig(ctx, journalDir, "remote.origin.url", "ext::sh -c exec% sleep% 10% 2>/dev/null"), IsNil)
+
+				err := cmd.Exec(ctx, []string{"-timeout", "100ms"})
+				c.Assume(err, Not(IsNil))
+				c.Expect(err.Error(), Equals, "fetching origin timed out after 100ms")
+			})
+
+			c.Specify("if the journal doesn't have any remotes", func() {
+				c.Assume(git.Command(journalDir, "remote", "remove", "origin").Run(), IsNil)
+				c.Expect(cmd.Exec(ctx, nil), Equals, ErrNoRemotes)
+			})
+		})
+	})
+}
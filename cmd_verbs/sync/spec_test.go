@@ -0,0 +1,14 @@
+package sync
+
+import (
+	"github.com/ghthor/gospec"
+	"testing"
+)
+
+func TestUnitSpecs(t *testing.T) {
+	r := gospec.NewRunner()
+
+	r.AddSpec(DescribeSyncCmd)
+
+	gospec.MainGoTest(r, t)
+}
@@ -0,0 +1,122 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ghthor/journal/config"
+)
+
+var Cmd = NewCmd(nil)
+
+type cmd struct {
+	flagSet *flag.FlagSet
+
+	wd string // working directory
+
+	// Verify every commit instead of only the commits that changed entries
+	all *bool
+
+	// Output for the report
+	Stdout io.Writer
+}
+
+// Returned when commits aren't signed or their signatures aren't good
+type UnverifiedCommitsError struct {
+	Count int
+}
+
+func (e UnverifiedCommitsError) Error() string {
+	return fmt.Sprintf("%d commits don't have a good signature", e.Count)
+}
+
+func IsUnverifiedCommitsError(err error) bool {
+	_, ok := err.(UnverifiedCommitsError)
+	return ok
+}
+
+func NewCmd(flagSet *flag.FlagSet) *cmd {
+	if flagSet == nil {
+		flagSet = flag.NewFlagSet("verify", flag.ExitOnError)
+	}
+
+	c := &cmd{
+		flagSet: flagSet,
+		Stdout:  os.Stdout,
+	}
+
+	c.all = c.flagSet.Bool("all", false, "verify every commit instead of only the commits that changed entries")
+
+	return c
+}
+
+func (c *cmd) SetWd(directory string) {
+	c.wd = directory
+}
+
+func (c *cmd) Exec(ctx context.Context, args []string) error {
+	c.flagSet.Parse(args)
+
+	a := c.flagSet.Args()
+
+	var path string
+
+	switch len(a) {
+	case 0:
+		path = c.wd
+	case 1:
+		path = a[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.wd, path)
+		}
+
+	default:
+		return errors.New("too many arguments")
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	repo, err := cfg.Repository(path)
+	if err != nil {
+		return err
+	}
+
+	kind, filter := "entry commits", filepath.Join(path, "entry")
+	if *c.all {
+		kind, filter = "commits", ""
+	}
+
+	verifications, err := repo.Verify(ctx, "HEAD", filter)
+	if err != nil {
+		return err
+	}
+
+	unverified := 0
+	for _, v := range verifications {
+		if v.Status.IsGood() {
+			continue
+		}
+
+		unverified++
+		fmt.Fprintf(c.Stdout, "%.7s %s: %s\n", v.Hash, v.Subject, v.Status)
+	}
+
+	if unverified > 0 {
+		return UnverifiedCommitsError{unverified}
+	}
+
+	fmt.Fprintf(c.Stdout, "all %d %s have a good signature\n", len(verifications), kind)
+	return nil
+}
+
+func (c cmd) Summary() string {
+	return "report the commits that aren't signed or have a bad signature"
+}
@@ -0,0 +1,102 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ghthor/journal/config"
+	"github.com/ghthor/journal/git"
+	initialize "github.com/ghthor/journal/init"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeVerifyCmd(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("the `verify` command", func() {
+		// Create a temporary journal
+		journalDir, err := ioutil.TempDir("", "verify_cmd_desc_")
+		c.Assume(err, IsNil)
+		defer func() {
+			c.Assume(os.RemoveAll(journalDir), IsNil)
+		}()
+
+		commitable, err := initialize.Journal(ctx, journalDir)
+		c.Assume(err, IsNil)
+		c.Assume(git.Commit(ctx, commitable), IsNil)
+
+		// Sign with a throwaway key that is the only allowed signer
+		keyDir, err := ioutil.TempDir("", "verify_cmd_key_")
+		c.Assume(err, IsNil)
+		defer os.RemoveAll(keyDir)
+
+		key := filepath.Join(keyDir, "key")
+		c.Assume(exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).Run(), IsNil)
+
+		publicKey, err := ioutil.ReadFile(key + ".pub")
+		c.Assume(err, IsNil)
+		c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "allowed_signers"), append([]byte("journal@example.com "), publicKey...), 0600), IsNil)
+
+		c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "journal.json"), []byte(`{"git": {"sign": {
+			"format": "ssh",
+			"key": "`+key+`",
+			"allowedSigners": "allowed_signers"
+		}}}`), 0600), IsNil)
+
+		cfg, err := config.Load(journalDir)
+		c.Assume(err, IsNil)
+
+		repo, err := cfg.Repository(journalDir)
+		c.Assume(err, IsNil)
+
+		c.Assume(repo.Add(ctx, "journal.json"), IsNil)
+		c.Assume(repo.Add(ctx, "allowed_signers"), IsNil)
+		c.Assume(repo.Commit(ctx, "sign commits"), IsNil)
+
+		commitEntry := func(filename, msg string) {
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "entry", filename), []byte("# "+msg+"\n"), 0600), IsNil)
+			c.Assume(repo.Add(ctx, filepath.Join("entry", filename)), IsNil)
+			c.Assume(repo.Commit(ctx, msg), IsNil)
+		}
+
+		output := bytes.NewBuffer(nil)
+
+		cmd := NewCmd(nil)
+		cmd.SetWd(journalDir)
+		cmd.Stdout = output
+
+		c.Specify("will report that every entry commit has a good signature", func() {
+			commitEntry("2015-01-01-0000-UTC", "a signed entry")
+
+			c.Expect(cmd.Exec(ctx, nil), IsNil)
+			c.Expect(output.String(), Equals, "all 1 entry commits have a good signature\n")
+		})
+
+		c.Specify("will report the entry commits that aren't signed", func() {
+			commitEntry("2015-01-01-0000-UTC", "a signed entry")
+
+			c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "entry", "2015-01-02-0000-UTC"), []byte("# an unsigned entry\n"), 0600), IsNil)
+			c.Assume(git.AddFilepath(ctx, journalDir, "entry"), IsNil)
+			c.Assume(git.CommitWithMessage(ctx, journalDir, "an unsigned entry"), IsNil)
+
+			hash, err := repo.RevParse(ctx, "HEAD")
+			c.Assume(err, IsNil)
+
+			err = cmd.Exec(ctx, nil)
+			c.Expect(err, Equals, UnverifiedCommitsError{1})
+			c.Expect(output.String(), Equals, hash[:7]+" an unsigned entry: not signed\n")
+		})
+
+		c.Specify("will report every commit that isn't signed", func() {
+			err := cmd.Exec(ctx, []string{"-all"})
+			c.Expect(IsUnverifiedCommitsError(err), IsTrue)
+			c.Expect(output.String(), Not(Equals), "")
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package verify
+
+import (
+	"github.com/ghthor/gospec"
+	"testing"
+)
+
+func TestUnitSpecs(t *testing.T) {
+	r := gospec.NewRunner()
+
+	r.AddSpec(DescribeVerifyCmd)
+
+	gospec.MainGoTest(r, t)
+}
@@ -0,0 +1,240 @@
+// Package config loads a journal's configuration
+// from the journal.json file stored in the journal's directory.
+// A journal without a configuration file uses the defaults.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/idea"
+)
+
+// The filename of the configuration file within a journal directory
+const Filename = "journal.json"
+
+type Config struct {
+	// The statuses an idea can have. If defined this
+	// list replaces the default statuses.
+	Statuses idea.Statuses `json:"statuses,omitempty"`
+
+	Git Git `json:"git"`
+}
+
+// Configures how the journal's git repository is accessed
+type Git struct {
+	// The backend used to access the repository, "exec" or "go-git".
+	// If empty the exec backend is used if git is installed.
+	Backend string `json:"backend,omitempty"`
+
+	// How `journal new` commits the ideas and the entry,
+	// "separate" or "combined". If empty the changes are
+	// committed separately.
+	Commits string `json:"commits,omitempty"`
+
+	// Which dates of a commit are set from the entry, "author",
+	// "all" or "none". If empty only the author date is set.
+	Dates string `json:"dates,omitempty"`
+
+	// How commits are signed and verified. Relative
+	// paths are relative to the journal's directory.
+	Sign git.Signing `json:"sign"`
+
+	// The remotes `journal sync` pulls from and pushes to.
+	// If empty every remote configured in git is synced.
+	Remotes []string `json:"remotes,omitempty"`
+
+	// How `journal sync` combines the changes from a remote,
+	// "merge" or "rebase". If empty the changes are merged.
+	Sync string `json:"sync,omitempty"`
+
+	// Go templates that render the commit messages by the kind of
+	// commit, "entry", "ideaCreated", "ideaUpdated", "init", "fix"
+	// or "default". A kind of commit without a template uses the
+	// "default" template and without either the message isn't changed.
+	Messages map[string]string `json:"messages,omitempty"`
+}
+
+// The ways `journal sync` can combine the changes from a remote
+const (
+	MergeSync  = "merge"
+	RebaseSync = "rebase"
+)
+
+// The ways `journal new` can commit the changes to the ideas and the entry
+const (
+	// Each modified idea is committed and then the entry is committed
+	SeparateCommits = "separate"
+
+	// The ideas and the entry are committed together
+	CombinedCommits = "combined"
+)
+
+// The dates of a commit that can be set from an entry
+const (
+	// The author date is when the entry was opened
+	AuthorDates = "author"
+
+	// The committer date is also set to when the entry was closed
+	AllDates = "all"
+
+	// Both dates are when the commit is made
+	NoDates = "none"
+)
+
+// Returned when the configured commit style doesn't exist
+type UnknownCommitsError struct {
+	Commits string
+}
+
+func (e UnknownCommitsError) Error() string {
+	return fmt.Sprintf("unknown commit style: %s", e.Commits)
+}
+
+// Returns an error if commits isn't a commit style
+func ValidateCommits(commits string) error {
+	switch commits {
+	case "", SeparateCommits, CombinedCommits:
+		return nil
+	}
+	return UnknownCommitsError{commits}
+}
+
+// Returns the configuration used by a journal
+// that doesn't have a configuration file
+func Default() Config {
+	return Config{
+		Statuses: idea.DefaultStatuses,
+	}
+}
+
+// Load the configuration for the journal in directory.
+// Any value that isn't set in the configuration file
+// will use the default value.
+func Load(directory string) (Config, error) {
+	c := Default()
+
+	data, err := ioutil.ReadFile(filepath.Join(directory, Filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return c, err
+	}
+
+	// Decode into an empty config so lists replace the defaults
+	var fileConfig Config
+	err = json.Unmarshal(data, &fileConfig)
+	if err != nil {
+		return c, fmt.Errorf("error parsing %s: %v", filepath.Join(directory, Filename), err)
+	}
+
+	if fileConfig.Statuses != nil {
+		c.Statuses = fileConfig.Statuses
+	}
+
+	c.Git = fileConfig.Git
+
+	err = c.Statuses.Validate()
+	if err != nil {
+		return c, fmt.Errorf("error in %s: %v", filepath.Join(directory, Filename), err)
+	}
+
+	switch c.Git.Backend {
+	case git.AutoBackend, git.ExecBackend, git.GoGitBackend:
+	default:
+		return c, fmt.Errorf("error in %s: %v", filepath.Join(directory, Filename), git.UnknownBackendError{Backend: c.Git.Backend})
+	}
+
+	err = ValidateCommits(c.Git.Commits)
+	if err != nil {
+		return c, fmt.Errorf("error in %s: %v", filepath.Join(directory, Filename), err)
+	}
+
+	err = c.Git.Sign.Validate()
+	if err != nil {
+		return c, fmt.Errorf("error in %s: %v", filepath.Join(directory, Filename), err)
+	}
+
+	switch c.Git.Sync {
+	case "", MergeSync, RebaseSync:
+	default:
+		return c, fmt.Errorf("error in %s: unknown sync method: %s", filepath.Join(directory, Filename), c.Git.Sync)
+	}
+
+	switch c.Git.Dates {
+	case "", AuthorDates, AllDates, NoDates:
+	default:
+		return c, fmt.Errorf("error in %s: unknown commit dates: %s", filepath.Join(directory, Filename), c.Git.Dates)
+	}
+
+	_, err = git.ParseMsgTemplates(c.Git.Messages)
+	if err != nil {
+		return c, fmt.Errorf("error in %s: %v", filepath.Join(directory, Filename), err)
+	}
+
+	return c, nil
+}
+
+// Open the idea directory store within the journal
+// configured with the journal's idea statuses.
+func (c Config) IdeaStore(directory string) (*idea.DirectoryStore, error) {
+	store, err := idea.NewDirectoryStore(filepath.Join(directory, "idea"))
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.SetStatuses(c.Statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Returns the commitable with only the dates that are configured to be kept
+func (c Config) CommitDates(commitable git.Commitable) git.Commitable {
+	dates := commitable.CommitDates()
+
+	switch c.Git.Dates {
+	case AllDates:
+		return commitable
+	case NoDates:
+		dates = git.Dates{}
+	default:
+		dates.Committer = time.Time{}
+	}
+
+	return git.WithDates(commitable, dates)
+}
+
+// Open the git repository containing the journal using the
+// configured backend. The repository renders the message of
+// every commitable with the configured commit message templates.
+func (c Config) Repository(directory string) (git.Repository, error) {
+	messages, err := git.ParseMsgTemplates(c.Git.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	signing := c.Git.Sign
+
+	if signing.Format == git.SSHSigning && len(signing.Key) != 0 && !filepath.IsAbs(signing.Key) {
+		signing.Key = filepath.Join(directory, signing.Key)
+	}
+
+	if len(signing.AllowedSigners) != 0 && !filepath.IsAbs(signing.AllowedSigners) {
+		signing.AllowedSigners = filepath.Join(directory, signing.AllowedSigners)
+	}
+
+	return git.OpenWith(directory, git.Options{
+		Backend:  c.Git.Backend,
+		Signing:  signing,
+		Messages: messages,
+	})
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/idea"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeConfig(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("a journal configuration", func() {
+		d, err := ioutil.TempDir("", "config_")
+		c.Assume(err, IsNil)
+		defer func() {
+			c.Assume(os.RemoveAll(d), IsNil)
+		}()
+
+		writeConfig := func(data string) {
+			c.Assume(ioutil.WriteFile(filepath.Join(d, Filename), []byte(data), 0600), IsNil)
+		}
+
+		c.Specify("will use the defaults if there isn't a configuration file", func() {
+			cfg, err := Load(d)
+			c.Assume(err, IsNil)
+			c.Expect(fmt.Sprint(cfg.Statuses), Equals, fmt.Sprint(idea.DefaultStatuses))
+		})
+
+		c.Specify("can define the idea statuses", func() {
+			writeConfig(`{
+	"statuses": [
+		{"name": "active", "carryOver": true},
+		{"name": "blocked", "carryOver": true, "transitions": ["active"]},
+		{"name": "someday"}
+	]
+}`)
+
+			cfg, err := Load(d)
+			c.Assume(err, IsNil)
+			c.Expect(fmt.Sprint(cfg.Statuses), Equals, fmt.Sprint(idea.Statuses{
+				{Name: "active", CarryOver: true},
+				{Name: "blocked", CarryOver: true, Transitions: []string{"active"}},
+				{Name: "someday"},
+			}))
+		})
+
+		c.Specify("can choose the git backend", func() {
+			writeConfig(`{"git": {"backend": "go-git"}}`)
+
+			cfg, err := Load(d)
+			c.Assume(err, IsNil)
+			c.Expect(cfg.Git.Backend, Equals, git.GoGitBackend)
+			c.Expect(fmt.Sprint(cfg.Statuses), Equals, fmt.Sprint(idea.DefaultStatuses))
+
+			_, err = git.InitRepository(ctx, d, git.ExecBackend)
+			c.Assume(err, IsNil)
+
+			repo, err := cfg.Repository(d)
+			c.Assume(err, IsNil)
+
+			status, err := repo.Status(ctx)
+			c.Assume(err, IsNil)
+			c.Expect(fmt.Sprint(status), Equals, "[?? journal.json]")
+		})
+
+		c.Specify("can choose how entries are committed", func() {
+			writeConfig(`{"git": {"commits": "combined"}}`)
+
+			cfg, err := Load(d)
+			c.Assume(err, IsNil)
+			c.Expect(cfg.Git.Commits, Equals, CombinedCommits)
+		})
+
+		c.Specify("can choose which commit dates are set from an entry", func() {
+			dated := git.Changes{Dates: git.Dates{
+				Author:    time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+				Committer: time.Date(2015, 1, 1, 1, 0, 0, 0, time.UTC),
+			}}
+
+			c.Specify("and will only set the author date by default", func() {
+				dates := Default().CommitDates(dated).CommitDates()
+				c.Expect(dates.Author, Equals, dated.Dates.Author)
+				c.Expect(dates.Committer.IsZero(), IsTrue)
+			})
+
+			c.Specify("and will set all the dates", func() {
+				writeConfig(`{"git": {"dates": "all"}}`)
+
+				cfg, err := Load(d)
+				c.Assume(err, IsNil)
+				c.Expect(cfg.CommitDates(dated).CommitDates(), Equals, dated.Dates)
+			})
+
+			c.Specify("and will set none of the dates", func() {
+				writeConfig(`{"git": {"dates": "none"}}`)
+
+				cfg, err := Load(d)
+				c.Assume(err, IsNil)
+				c.Expect(cfg.CommitDates(dated).CommitDates(), Equals, git.Dates{})
+			})
+		})
+
+		c.Specify("can render the commit messages with templates", func() {
+			writeConfig(`{"git": {"messages": {
+	"ideaCreated": "idea #{{.Idea.Id}}: {{.Idea.Name}}",
+	"default": "journal: {{.Msg}}"
+}}}`)
+
+			cfg, err := Load(d)
+			c.Assume(err, IsNil)
+
+			_, err = git.InitRepository(ctx, d, git.ExecBackend)
+			c.Assume(err, IsNil)
+
+			repo, err := cfg.Repository(d)
+			c.Assume(err, IsNil)
+
+			created := &git.Changes{
+				Dir: d,
+				Msg: "idea - created - 1",
+				Description: git.Description{
+					Kind: git.IdeaCreatedCommit,
+					Idea: git.IdeaDescription{Id: 1, Name: "an idea", Status: "active"},
+				},
+			}
+			created.Add(git.ChangedFile(Filename))
+			c.Assume(git.CommitTo(ctx, repo, created), IsNil)
+			c.Assume(git.CommitEmptyTo(ctx, repo, &git.Changes{Dir: d, Msg: "begin"}), IsNil)
+
+			log, err := repo.Log(ctx, "HEAD", 0)
+			c.Assume(err, IsNil)
+			c.Assume(len(log), Equals, 2)
+			c.Expect(log[0].Subject(), Equals, "journal: begin")
+			c.Expect(log[1].Subject(), Equals, "idea #1: an idea")
+		})
+
+		c.Specify("will fail to load", func() {
+			c.Specify("if it isn't valid json", func() {
+				writeConfig(`{"statuses":`)
+				_, err := Load(d)
+				c.Expect(err, Not(IsNil))
+			})
+
+			c.Specify("if the git backend doesn't exist", func() {
+				writeConfig(`{"git": {"backend": "svn"}}`)
+				_, err := Load(d)
+				c.Expect(err, Not(IsNil))
+			})
+
+			c.Specify("if the commit dates don't exist", func() {
+				writeConfig(`{"git": {"dates": "committer"}}`)
+				_, err := Load(d)
+				c.Expect(err, Not(IsNil))
+			})
+
+			c.Specify("if the commit style doesn't exist", func() {
+				writeConfig(`{"git": {"commits": "squashed"}}`)
+				_, err := Load(d)
+				c.Expect(err, Not(IsNil))
+			})
+
+			c.Specify("if a commit message template isn't valid", func() {
+				writeConfig(`{"git": {"messages": {"entry": "{{.Title"}}}`)
+				_, err := Load(d)
+				c.Expect(err, Not(IsNil))
+			})
+
+			c.Specify("if a commit message template is for a kind of commit that doesn't exist", func() {
+				writeConfig(`{"git": {"messages": {"merge": "{{.Msg}}"}}}`)
+				_, err := Load(d)
+				c.Expect(err, Not(IsNil))
+			})
+
+			c.Specify("if the idea statuses are invalid", func() {
+				writeConfig(`{"statuses": [{"name": "active", "transitions": ["unknown"]}]}`)
+				_, err := Load(d)
+				c.Expect(err, Not(IsNil))
+			})
+		})
+	})
+}
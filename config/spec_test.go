@@ -0,0 +1,14 @@
+package config
+
+import (
+	"github.com/ghthor/gospec"
+	"testing"
+)
+
+func TestUnitSpecs(t *testing.T) {
+	r := gospec.NewRunner()
+
+	r.AddSpec(DescribeConfig)
+
+	gospec.MainGoTest(r, t)
+}
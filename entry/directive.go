@@ -0,0 +1,96 @@
+package entry
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/ghthor/journal/idea"
+)
+
+// Matches a line containing a directive to include an idea in the entry
+var ideaDirectiveRegexp = regexp.MustCompile(`^\s*{{\s*idea\s+(\d+)\s*}}\s*$`)
+
+// Replaces the `{{idea N}}` lines in the entry with the block of idea N.
+// The directives are removed and the ideas that aren't already in
+// the entry are appended after the entry's ideas. Each idea is
+// retrieved using include. Directives in fenced code blocks are ignored.
+func (e *openEntry) ExpandIdeaDirectives(include func(id uint) (idea.Idea, error)) error {
+	filename := filepath.Join(e.directory, e.openedAt.Format(FilenameLayout))
+
+	f, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	ids := make([]uint, 0, 2)
+
+	tokenizer := idea.NewTokenizer(f)
+	for tokenizer.Scan() {
+		token := tokenizer.Token()
+
+		if !token.Fenced {
+			if m := ideaDirectiveRegexp.FindStringSubmatch(token.Text); m != nil {
+				id, err := strconv.ParseUint(m[1], 10, 0)
+				if err != nil {
+					return err
+				}
+
+				ids = append(ids, uint(id))
+				continue
+			}
+		}
+
+		buf.WriteString(token.Text)
+	}
+
+	if err := tokenizer.Err(); err != nil {
+		return err
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	if err := f.Truncate(int64(buf.Len())); err != nil {
+		return err
+	}
+
+	ideas, err := e.Ideas()
+	if err != nil {
+		return err
+	}
+
+	inEntry := make(map[uint]bool, len(ideas)+len(ids))
+	for _, i := range ideas {
+		inEntry[i.Id] = true
+	}
+
+	for _, id := range ids {
+		if inEntry[id] {
+			continue
+		}
+
+		i, err := include(id)
+		if err != nil {
+			return err
+		}
+
+		ideas = append(ideas, i)
+		inEntry[id] = true
+	}
+
+	return e.WriteIdeas(ideas)
+}
@@ -0,0 +1,46 @@
+package entry
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Creates an EditorProcess that edits the file using the editor
+// named by envEditor, the value of the $EDITOR variable.
+func NewEnvEditor(envEditor string, filename string) (*exec.Cmd, error) {
+	// Enable the $EDITOR variable to
+	// contain a string such a "emacs -nw"
+	editorArgs := strings.Split(envEditor, " ")
+
+	// Assume that the first item in the split list
+	// is the executable name, such as editorArgs[0] == "vim"
+	// and look it up.
+	editorBin, err := exec.LookPath(editorArgs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var editorCmd *exec.Cmd
+
+	// Create an *exec.Cmd that will be used to edit the file
+	switch editorArgs[0] {
+	case "vim":
+		editorCmd = exec.Command(editorBin, "+set spell", filename)
+	case "emacs":
+		// ignore the "emacs" token from the editorArgs slice
+		// and append the filename to the end of it
+		editorArgs = append(editorArgs[1:], filename)
+		editorCmd = exec.Command(editorBin, editorArgs...)
+	default:
+		// Support for an editor is explicit
+		return nil, fmt.Errorf("%v is unimplemented", editorBin)
+	}
+
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	editorCmd.Stdin = os.Stdin
+
+	return editorCmd, nil
+}
@@ -0,0 +1,35 @@
+package entry
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeEnvEditor(c gospec.Context) {
+	c.Specify("the environment editor", func() {
+		c.Specify("will be vim", func() {
+			cmd, err := NewEnvEditor("vim", "entryFilename")
+			c.Assume(err, IsNil)
+
+			c.Expect(filepath.Base(cmd.Args[0]), Equals, "vim")
+			c.Expect(strings.Join(cmd.Args[1:], " "), Equals, "+set spell entryFilename")
+		})
+
+		c.Specify("will be emacs", func() {
+			cmd, err := NewEnvEditor("emacs", "entryFilename")
+			c.Assume(err, IsNil)
+
+			c.Expect(filepath.Base(cmd.Args[0]), Equals, "emacs")
+			c.Expect(strings.Join(cmd.Args[1:], " "), Equals, "entryFilename")
+
+			cmd, err = NewEnvEditor("emacs -nw", "entryFilename")
+			c.Assume(err, IsNil)
+
+			c.Expect(filepath.Base(cmd.Args[0]), Equals, "emacs")
+			c.Expect(strings.Join(cmd.Args[1:], " "), Equals, "-nw entryFilename")
+		})
+	})
+}
@@ -1,12 +1,12 @@
 package entry
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
-	"io"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
 	"time"
@@ -18,14 +18,26 @@ import (
 //A layout to use as the entry's filename
 const FilenameLayout = "2006-01-02-1504-MST"
 
-var entryTmpl = template.Must(template.New("entry").Parse(
+// Matches the note an overdue idea is flagged with. The notes
+// are only shown while the entry is open and are removed when
+// it's closed so they aren't committed with the entry.
+var overdueNote = regexp.MustCompile(`^<!-- OVERDUE \[[0-9]+\] .* was due [0-9]{4}-[0-9]{2}-[0-9]{2} -->$`)
+
+// Ideas are written in the canonical idea format
+// so they are read back unmodified
+var entryTmpl = template.Must(template.New("entry").Funcs(template.FuncMap{
+	"encode": func(i idea.Idea) (string, error) {
+		data, err := idea.Encode(i)
+		return string(data), err
+	},
+}).Parse(
 	`{{.OpenedAt}}
 
 # Title(will be used as commit message)
 TODO Make this some random quote or something stupid
-{{range .ActiveIdeas}}
-## [{{.Status}}]{{if .Id}} [{{.Id}}]{{end}} {{.Name}}
-{{.Body}}{{end}}`))
+{{range .OverdueIdeas}}<!-- OVERDUE [{{.Id}}] {{.Name}} was due {{.Due}} -->
+{{end}}{{range .ActiveIdeas}}
+{{encode .}}{{end}}`))
 
 type NewEntry interface {
 	Open(now time.Time, ideas []idea.Idea) (OpenEntry, error)
@@ -39,6 +51,8 @@ type EditorProcess interface {
 type OpenEntry interface {
 	OpenedAt() time.Time
 	Ideas() ([]idea.Idea, error)
+	WriteIdeas([]idea.Idea) error
+	ExpandIdeaDirectives(include func(id uint) (idea.Idea, error)) error
 
 	Edit(EditorProcess) (OpenEntry, error)
 
@@ -64,18 +78,46 @@ func (e *newEntry) Open(openedAt time.Time, ideas []idea.Idea) (OpenEntry, error
 	}
 	defer f.Close()
 
+	type overdueIdea struct {
+		idea.Idea
+		Due string
+	}
+
 	type entry struct {
-		OpenedAt    string
-		ActiveIdeas []idea.Idea
+		OpenedAt     string
+		OverdueIdeas []overdueIdea
+		ActiveIdeas  []idea.Idea
+	}
+
+	// Deferred ideas are hidden until their date
+	// and overdue ideas are listed before the ideas
+	data := entry{
+		OpenedAt:    openedAt.Format(time.UnixDate),
+		ActiveIdeas: make([]idea.Idea, 0, len(ideas)),
+	}
+
+	for _, i := range ideas {
+		schedule, err := i.Schedule()
+		if err != nil {
+			return nil, fmt.Errorf("idea %d: %v", i.Id, err)
+		}
+
+		if schedule.IsDeferred(openedAt) {
+			continue
+		}
+
+		if schedule.IsOverdue(openedAt) {
+			data.OverdueIdeas = append(data.OverdueIdeas, overdueIdea{i, schedule.Due.Format(idea.DateLayout)})
+		}
+
+		data.ActiveIdeas = append(data.ActiveIdeas, i)
 	}
 
-	err = entryTmpl.Execute(f, entry{
-		openedAt.Format(time.UnixDate),
-		ideas,
-	})
+	err = entryTmpl.Execute(f, data)
 	if err != nil {
 		return nil, err
 	}
+	ideas = data.ActiveIdeas
 
 	return &openEntry{e.directory, openedAt, ideas}, nil
 }
@@ -101,15 +143,71 @@ func (e *openEntry) Ideas() ([]idea.Idea, error) {
 	ideas := make([]idea.Idea, 0, len(e.ideas))
 	ideaScanner := idea.NewIdeaScanner(f)
 	for ideaScanner.Scan() {
-		if err := ideaScanner.Err(); err != nil {
-			return nil, err
-		}
 		ideas = append(ideas, *ideaScanner.Idea())
 	}
+
+	if err := ideaScanner.Err(); err != nil {
+		return nil, err
+	}
+
 	e.ideas = ideas
 	return ideas, nil
 }
 
+// Replaces the ideas in the entry with the ideas
+func (e *openEntry) WriteIdeas(ideas []idea.Idea) error {
+	filename := filepath.Join(e.directory, e.openedAt.Format(FilenameLayout))
+
+	f, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+
+	// Keep the lines before the first idea
+	tokenizer := idea.NewTokenizer(f)
+	for tokenizer.Scan() {
+		if tokenizer.Token().Kind == idea.HeaderToken {
+			break
+		}
+		buf.WriteString(tokenizer.Token().Text)
+	}
+
+	if err := tokenizer.Err(); err != nil {
+		return err
+	}
+
+	b := append(bytes.TrimRight(buf.Bytes(), "\n"), '\n')
+	buf = bytes.NewBuffer(b)
+
+	for _, i := range ideas {
+		data, err := idea.Encode(i)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteByte('\n')
+		buf.Write(data)
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	if err := f.Truncate(int64(buf.Len())); err != nil {
+		return err
+	}
+
+	e.ideas = ideas
+	return nil
+}
+
 func (e *openEntry) Edit(proc EditorProcess) (OpenEntry, error) {
 	err := proc.Start()
 	if err != nil {
@@ -130,37 +228,40 @@ func (e *openEntry) Close(closedAt time.Time) (ClosedEntry, error) {
 	}
 	defer f.Close()
 
-	fr := bufio.NewReader(f)
+	tokenizer := idea.NewTokenizer(f)
 	buf := bytes.NewBuffer(make([]byte, 0, 1024))
 
 	commitMsg := ""
 
 	// Find the start of the Idea's list
 	// Collect the Lines up till then
-	for {
-		line, err := fr.ReadString('\n')
-		if err != nil && err == io.EOF {
+	for tokenizer.Scan() {
+		token := tokenizer.Token()
+
+		// Is the Start of an Idea block
+		if token.Kind == idea.HeaderToken {
 			break
-		} else if err != nil {
-			return nil, err
 		}
 
-		// Is a commit msg header
-		if i := strings.Index(line, "# "); i == 0 {
-			commitMsg = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		if !token.Fenced && overdueNote.MatchString(strings.TrimRight(token.Text, "\n")) {
+			continue
 		}
 
-		// Is the Start of an Idea block
-		if i := strings.Index(line, "## ["); i != -1 {
-			break
+		// Is a commit msg header
+		if !token.Fenced && strings.HasPrefix(token.Text, "# ") {
+			commitMsg = strings.TrimSpace(strings.TrimPrefix(token.Text, "# "))
 		}
 
-		_, err = buf.WriteString(line)
+		_, err = buf.WriteString(token.Text)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+
 	// Commit Msg Check
 	if len(commitMsg) == 0 {
 		return nil, ErrNoCommitMsg
@@ -217,3 +318,17 @@ func (e *closedEntry) Changes() []git.CommitableChange {
 }
 
 func (e *closedEntry) CommitMsg() string { return e.commitMsg }
+
+// The entry is authored when it was opened and committed when it was closed
+func (e *closedEntry) CommitDates() git.Dates {
+	return git.Dates{Author: e.openedAt, Committer: e.closedAt}
+}
+
+func (e *closedEntry) CommitDescription() git.Description {
+	return git.Description{
+		Kind:     git.EntryCommit,
+		Title:    e.commitMsg,
+		OpenedAt: e.openedAt,
+		ClosedAt: e.closedAt,
+	}
+}
@@ -61,6 +61,68 @@ func DescribeAnEntry(c gospec.Context) {
 					c.Expect(idea, Equals, ideas[i])
 				}
 			})
+
+			c.Specify("with ideas that are scheduled", func() {
+				ideas := []idea.Idea{{
+					Name:   "Deferred Idea",
+					Status: idea.IS_Active,
+					Id:     1,
+					Body:   "Defer-Until: 2006-01-02\n",
+				}, {
+					Name:   "Overdue Idea",
+					Status: idea.IS_Active,
+					Id:     2,
+					Body:   "Due: 2005-12-31\n",
+				}, {
+					Name:   "Idea Due Today",
+					Status: idea.IS_Active,
+					Id:     3,
+					Body:   "Due: 2006-01-01\nDefer-Until: 2006-01-01\n",
+				}}
+
+				oe, err := ne.Open(t, ideas)
+				c.Assume(err, IsNil)
+
+				c.Specify("and will hide the ideas that are deferred", func() {
+					actualIdeas, err := oe.Ideas()
+					c.Assume(err, IsNil)
+					c.Expect(actualIdeas, ContainsExactly, ideas[1:])
+				})
+
+				c.Specify("and will flag the ideas that are overdue", func() {
+					data, err := ioutil.ReadFile(filepath.Join(td, t.Format(FilenameLayout)))
+					c.Assume(err, IsNil)
+					c.Expect(string(data), Equals, `Sun Jan  1 01:00:00 UTC 2006
+
+# Title(will be used as commit message)
+TODO Make this some random quote or something stupid
+<!-- OVERDUE [2] Overdue Idea was due 2005-12-31 -->
+
+## [active] [2] Overdue Idea
+Due: 2005-12-31
+
+## [active] [3] Idea Due Today
+Due: 2006-01-01
+Defer-Until: 2006-01-01
+`)
+				})
+
+				c.Specify("and won't save the overdue flags when closed", func() {
+					closedAt := t.Add(10 * time.Minute)
+					_, err := oe.Close(closedAt)
+					c.Assume(err, IsNil)
+
+					data, err := ioutil.ReadFile(filepath.Join(td, t.Format(FilenameLayout)))
+					c.Assume(err, IsNil)
+					c.Expect(string(data), Equals, `Sun Jan  1 01:00:00 UTC 2006
+
+# Title(will be used as commit message)
+TODO Make this some random quote or something stupid
+
+Sun Jan  1 01:10:00 UTC 2006
+`)
+				})
+			})
 		})
 		openedAt := time.Date(2006, time.January, 1, 1, 0, 0, 0, time.UTC)
 		closedAt := time.Date(2006, time.January, 1, 1, 10, 0, 0, time.UTC)
@@ -171,6 +233,70 @@ Sir Idea, The Third
 				c.Expect(err, IsNil)
 			})
 
+			c.Specify("can be closed with headers in fenced code blocks", func() {
+				err := ioutil.WriteFile(filename, []byte(
+					"Sun Jan  1 01:00:00 UTC 2006\n\n"+
+						"# The Title\n"+
+						"An idea is written like ## [status] name\n"+
+						"```\n"+
+						"# not the title\n"+
+						"## [active] [1] not an idea\n"+
+						"```\n\n"+
+						"## [active] [1] Active Idea\n"+
+						"Some text\n"), 0600)
+				c.Assume(err, IsNil)
+
+				ce, err := oe.Close(closedAt)
+				c.Assume(err, IsNil)
+				c.Expect(ce.CommitMsg(), Equals, "The Title")
+
+				actualBytes, err := ioutil.ReadFile(filename)
+				c.Assume(err, IsNil)
+				c.Expect(string(actualBytes), Equals, "Sun Jan  1 01:00:00 UTC 2006\n\n"+
+					"# The Title\n"+
+					"An idea is written like ## [status] name\n"+
+					"```\n"+
+					"# not the title\n"+
+					"## [active] [1] not an idea\n"+
+					"```\n\n"+
+					"Sun Jan  1 01:10:00 UTC 2006\n")
+			})
+
+			c.Specify("can expand idea directives into idea blocks", func() {
+				err := ioutil.WriteFile(filename, []byte(
+					"Sun Jan  1 01:00:00 UTC 2006\n\n"+
+						"# The Title\n"+
+						"{{idea 15}}\n"+
+						"```\n"+
+						"{{idea 16}}\n"+
+						"```\n\n"+
+						"## [active] [1] Active Idea\n"+
+						"Some text\n"+
+						"{{ idea 1 }}\n"), 0600)
+				c.Assume(err, IsNil)
+
+				included := make([]uint, 0, 2)
+				err = oe.ExpandIdeaDirectives(func(id uint) (idea.Idea, error) {
+					included = append(included, id)
+					return idea.Idea{Status: idea.IS_Active, Id: id, Name: "Included Idea", Body: "included\n"}, nil
+				})
+				c.Assume(err, IsNil)
+				c.Expect(len(included), Equals, 1)
+				c.Expect(included[0], Equals, uint(15))
+
+				actualBytes, err := ioutil.ReadFile(filename)
+				c.Assume(err, IsNil)
+				c.Expect(string(actualBytes), Equals, "Sun Jan  1 01:00:00 UTC 2006\n\n"+
+					"# The Title\n"+
+					"```\n"+
+					"{{idea 16}}\n"+
+					"```\n\n"+
+					"## [active] [1] Active Idea\n"+
+					"Some text\n\n"+
+					"## [active] [15] Included Idea\n"+
+					"included\n")
+			})
+
 			c.Specify("cannot be closed without a commit msg", func() {
 				err := ioutil.WriteFile(filename, []byte(
 					`
@@ -0,0 +1,93 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/idea"
+)
+
+// Matches the id in an idea header
+var ideaHeaderIdRegexp = regexp.MustCompile(`^(## \[[^\]]*\] \[)(\d+)(\])`)
+
+// Rewrite the ids of the ideas referenced in the entry's text
+// using the renumbered map. Returns true if the entry was modified.
+func RenumberIdeas(filename string, renumbered map[uint]uint) (bool, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+
+	modified := bytes.NewBuffer(make([]byte, 0, len(data)))
+
+	tokenizer := idea.NewTokenizer(bytes.NewReader(data))
+	for tokenizer.Scan() {
+		token := tokenizer.Token()
+		if token.Kind != idea.HeaderToken {
+			modified.WriteString(token.Text)
+			continue
+		}
+
+		modified.WriteString(ideaHeaderIdRegexp.ReplaceAllStringFunc(token.Text, func(header string) string {
+			parts := ideaHeaderIdRegexp.FindStringSubmatch(header)
+
+			id, err := strconv.ParseUint(parts[2], 10, 0)
+			if err != nil {
+				return header
+			}
+
+			newId, isRenumbered := renumbered[uint(id)]
+			if !isRenumbered {
+				return header
+			}
+
+			return fmt.Sprintf("%s%d%s", parts[1], newId, parts[3])
+		}))
+	}
+
+	if err := tokenizer.Err(); err != nil {
+		return false, err
+	}
+
+	if bytes.Equal(data, modified.Bytes()) {
+		return false, nil
+	}
+
+	return true, ioutil.WriteFile(filename, modified.Bytes(), 0600)
+}
+
+// Rewrite the idea ids referenced by every entry in the directory
+// that was created or modified by the branch being merged, MERGE_HEAD.
+// Returns a commitable containing the modified entries.
+func RenumberIdeasIn(ctx context.Context, directory string, renumbered map[uint]uint) (git.Commitable, error) {
+	o, err := git.Exec(ctx, directory, "diff", "--name-only", "--relative", "-z", "HEAD...MERGE_HEAD", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("error listing entries from MERGE_HEAD: %v", err)
+	}
+
+	changes := git.NewChangesIn(directory)
+	changes.Msg = "entry - renumbered ideas"
+
+	for _, filename := range bytes.Split(o, []byte{0}) {
+		if len(filename) == 0 {
+			continue
+		}
+
+		wasModified, err := RenumberIdeas(filepath.Join(directory, string(filename)), renumbered)
+		if err != nil {
+			return nil, err
+		}
+
+		if wasModified {
+			changes.Add(git.ChangedFile(string(filename)))
+		}
+	}
+
+	return changes, nil
+}
@@ -9,6 +9,7 @@ func TestUnitSpecs(t *testing.T) {
 	r := gospec.NewRunner()
 
 	r.AddSpec(DescribeAnEntry)
+	r.AddSpec(DescribeEnvEditor)
 
 	gospec.MainGoTest(r, t)
 }
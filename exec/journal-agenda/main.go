@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ghthor/journal/cmd"
+	verb "github.com/ghthor/journal/cmd_verbs/agenda"
+)
+
+const (
+	EC_OK int = iota
+	EC_WD_ERROR
+	EC_CMD_ERROR
+)
+
+var usagePrefix = `journal-agenda lists the ideas and tasks that are due soon
+
+Usage:
+    journal-agenda [-days N] [directory]
+
+`
+
+func main() {
+	flagSet := flag.NewFlagSet("journal-agenda", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Print(usagePrefix)
+		flagSet.PrintDefaults()
+	}
+
+	// Interrupting cancels the git process being waited on
+	ctx, stop := cmd.InterruptContext(context.Background())
+	defer stop()
+
+	cmd := verb.NewCmd(flagSet)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(EC_WD_ERROR)
+	}
+
+	cmd.SetWd(wd)
+
+	// Execute the command
+	err = cmd.Exec(ctx, os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(EC_CMD_ERROR)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ghthor/journal/cmd"
+	verb "github.com/ghthor/journal/cmd_verbs/idea"
+)
+
+const (
+	EC_OK int = iota
+	EC_WD_ERROR
+	EC_CMD_ERROR
+)
+
+var usagePrefix = `journal-idea manages the ideas stored in a journal
+
+Usage:
+    journal-idea subcommand [arguments]
+
+The subcommands are:
+    renumber [directory]                  resolve ideas created with the same id on both sides of a merge
+    move id --before id [directory]       move an active idea before another active idea
+    merge id id [directory]               merge the first idea into the second idea
+    split id [directory]                  split part of an idea into a new idea using $EDITOR
+`
+
+func main() {
+	flagSet := flag.NewFlagSet("journal-idea", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Print(usagePrefix)
+		flagSet.PrintDefaults()
+	}
+
+	// Interrupting cancels the git process being waited on
+	ctx, stop := cmd.InterruptContext(context.Background())
+	defer stop()
+
+	cmd := verb.NewCmd(flagSet)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(EC_WD_ERROR)
+	}
+
+	cmd.SetWd(wd)
+
+	// Execute the command
+	err = cmd.Exec(ctx, os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(EC_CMD_ERROR)
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 
+	"github.com/ghthor/journal/cmd"
 	verb "github.com/ghthor/journal/cmd_verbs/new"
 )
 
@@ -18,7 +20,7 @@ var usagePrefix = `
 journal-new updates the journal's storage format
 
 Usage:
-    journal-new [directory]
+    journal-new [-idea id]... [-commits separate|combined] [directory]
 
 `
 
@@ -29,6 +31,10 @@ func main() {
 		flagSet.PrintDefaults()
 	}
 
+	// Interrupting cancels the git process being waited on
+	ctx, stop := cmd.InterruptContext(context.Background())
+	defer stop()
+
 	cmd := verb.NewCmd(flagSet)
 
 	wd, err := os.Getwd()
@@ -40,7 +46,7 @@ func main() {
 	cmd.SetWd(wd)
 
 	// Execute the command
-	err = cmd.Exec(os.Args[1:])
+	err = cmd.Exec(ctx, os.Args[1:])
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(EC_CMD_ERROR)
@@ -1,6 +1,7 @@
 package case_0_static
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -142,7 +143,9 @@ func NewIn(directory string) (case_0_directory string, entries []string, err err
 	// Initialize a git repository in the case_0/ directory and commit all the entries
 	case_0_directory = filepath.Join(directory, "case_0")
 
-	err = git.Init(case_0_directory)
+	ctx := context.Background()
+
+	err = git.Init(ctx, case_0_directory)
 	if err != nil {
 		return "", nil, err
 	}
@@ -155,7 +158,7 @@ func NewIn(directory string) (case_0_directory string, entries []string, err err
 		changes := git.NewChangesIn(case_0_directory)
 		changes.Add(git.ChangedFile(entryFilename))
 		changes.Msg = fmt.Sprintf("Commit Msg | Entry %d\n", i+1)
-		err = changes.Commit()
+		err = changes.Commit(ctx)
 		if err != nil {
 			return "", nil, err
 		}
@@ -1,6 +1,7 @@
 package case_0_static_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -25,6 +26,8 @@ func TestUnitSpecs(t *testing.T) {
 }
 
 func DescribeNewCase0(c gospec.Context) {
+	ctx := context.Background()
+
 	tmpDir := func(prefix string) (directory string, cleanUp func()) {
 		directory, err := ioutil.TempDir("", prefix+"_")
 		c.Assume(err, IsNil)
@@ -73,7 +76,7 @@ func DescribeNewCase0(c gospec.Context) {
 
 			c.Specify("as a git repository", func() {
 				c.Expect(d, gittest.IsAGitRepository)
-				c.Expect(git.IsClean(d), IsNil)
+				c.Expect(git.IsClean(ctx, d), IsNil)
 
 				c.Specify("and contains committed entry", func() {
 					for i := 0; i < len(entries); i++ {
@@ -109,7 +112,7 @@ func DescribeNewCase0(c gospec.Context) {
 				reflogInfos, err := reflogDir.Readdir(0)
 				c.Assume(err, IsNil)
 
-				c.Expect(len(reflogInfos), Equals, 15)
+				c.Expect(len(reflogInfos), Equals, 16)
 			})
 		})
 
@@ -1,3 +1,4 @@
+//go:build ignore
 // +build ignore
 
 // Command that will rebuild the reflog of the changes that will
@@ -6,6 +7,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -39,7 +41,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	reflog, err := fix.Fix(journalDir)
+	reflog, err := fix.Fix(context.Background(), journalDir)
 	if err != nil {
 		log.Fatal(err)
 	}
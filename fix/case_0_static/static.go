@@ -420,7 +420,19 @@ index acdbfef..e2d10a5 100644
  Mon Jan  7 00:01:00 EST 2014
 `,
 
-	"case_0_fix_reflog/14": `journal - fix - completed
+	"case_0_fix_reflog/14": `journal - fix - merge drivers assigned
+
+diff --git a/.gitattributes b/.gitattributes
+new file mode 100644
+index 0000000..ede59c4
+--- /dev/null
++++ b/.gitattributes
+@@ -0,0 +1,2 @@
++idea/active merge=journal-active
++idea/nextid merge=journal-nextid
+`,
+
+	"case_0_fix_reflog/15": `journal - fix - completed
 `,
 
 	"case_0_fix_reflog/2": `journal - fix - idea directory store initialized
@@ -1,6 +1,8 @@
 package fix
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -17,6 +19,8 @@ import (
 )
 
 func DescribeFixingCase0(c gospec.Context) {
+	ctx := context.Background()
+
 	tmpDir := func(prefix string) (directory string, cleanUp func()) {
 		directory, err := ioutil.TempDir("", prefix+"_")
 		c.Assume(err, IsNil)
@@ -35,9 +39,14 @@ func DescribeFixingCase0(c gospec.Context) {
 		d, expectedEntries, err := case_0_static.NewIn(baseDir)
 		c.Assume(err, IsNil)
 
-		refLog, err := fixCase0(d)
+		report := bytes.NewBuffer(nil)
+		refLog, err := fixCase0(ctx, d, Options{Report: report})
 		c.Assume(err, IsNil)
 
+		c.Specify("without reporting ideas that only share a word as duplicates", func() {
+			c.Expect(report.String(), Equals, "")
+		})
+
 		c.Specify("by moving entries into `entry/`", func() {
 			info, err := os.Stat(filepath.Join(d, "entry"))
 			c.Expect(err, IsNil)
@@ -141,7 +150,7 @@ Mon Jan  7 00:01:00 EST 2014
 		})
 
 		c.Specify("and all changes will be commited", func() {
-			c.Expect(git.IsClean(d), IsNil)
+			c.Expect(git.IsClean(ctx, d), IsNil)
 
 			vfs := mapfs.New(case_0_static.Files)
 
@@ -0,0 +1,182 @@
+package fix
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghthor/journal/config"
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/lock"
+)
+
+// A preview of the changes fixing a journal would make
+type Plan struct {
+	// The commits fixing the journal would make, in the order of the refLog
+	Commits []git.LogEntry
+
+	// A unified diff of every file in the journal fixing would change
+	Diff string
+}
+
+// Fixes the journal in a temporary clone of its repository and returns
+// the commits that were made and the changes to the journal's files.
+// The clone shares the repository's objects, only checks out the journal's
+// directory and doesn't run any hooks. The journal and its repository
+// aren't changed.
+func DryRun(ctx context.Context, directory string, opts Options) (Plan, error) {
+	directory, err := filepath.Abs(directory)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	// Keep the journal from changing while it's cloned
+	l, err := lock.Journal(directory)
+	if err != nil {
+		return Plan{}, err
+	}
+	defer l.Release()
+
+	tmpDir, err := ioutil.TempDir("", "journal-fix-dry-run_")
+	if err != nil {
+		return Plan{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cloned, err := cloneJournal(ctx, directory, tmpDir)
+	if err != nil {
+		return Plan{}, fmt.Errorf("error cloning the repository containing %s: %v", directory, err)
+	}
+
+	repo, err := openRepository(cloned)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	start, err := repo.RevParse(ctx, "HEAD")
+	if err != nil {
+		return Plan{}, err
+	}
+
+	refLog, err := FixWith(ctx, cloned, opts)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	var plan Plan
+	if len(refLog) == 0 {
+		return plan, nil
+	}
+
+	// Open the repository again, fixing may have changed its configuration
+	repo, err = openRepository(cloned)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	for _, ref := range refLog {
+		commits, err := repo.Log(ctx, ref, 1)
+		if err != nil {
+			return Plan{}, err
+		}
+
+		plan.Commits = append(plan.Commits, commits...)
+	}
+
+	plan.Diff, err = repo.Diff(ctx, start, refLog[len(refLog)-1])
+	if err != nil {
+		return Plan{}, err
+	}
+
+	return plan, nil
+}
+
+func openRepository(directory string) (git.Repository, error) {
+	cfg, err := config.Load(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Repository(directory)
+}
+
+// Clones the repository containing the journal in directory into dst
+// at the journal's HEAD and returns the journal's directory in the clone.
+// The objects are shared with the repository and hooks are disabled.
+// If the journal is a subdirectory of the repository only the journal's
+// directory and the files at the root of the repository are checked out.
+func cloneJournal(ctx context.Context, directory, dst string) (string, error) {
+	gitDir, err := git.Exec(ctx, directory, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+
+	prefix, err := git.Exec(ctx, directory, "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", err
+	}
+
+	head, err := git.Exec(ctx, directory, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	// The git directory of a worktree or a submodule may be relative
+	src := strings.TrimSpace(string(gitDir))
+	if !filepath.IsAbs(src) {
+		src = filepath.Join(directory, src)
+	}
+
+	rel := strings.TrimSuffix(strings.TrimSpace(string(prefix)), "/")
+
+	_, err = git.Exec(ctx, directory, "clone", "--quiet", "--shared", "--no-checkout",
+		"--config", "core.hooksPath="+os.DevNull, src, dst)
+	if err != nil {
+		return "", err
+	}
+
+	if len(rel) > 0 {
+		_, err = git.Exec(ctx, dst, "sparse-checkout", "set", "--cone", rel)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = git.Exec(ctx, dst, "checkout", "--quiet", "-b", "journal-fix-dry-run", strings.TrimSpace(string(head)))
+	if err != nil {
+		return "", err
+	}
+
+	cloned := filepath.Join(dst, rel)
+
+	// Empty directories aren't cloned, they're created
+	// so the journal is detected the same in the clone
+	err = filepath.Walk(directory, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !fi.IsDir() {
+			return nil
+		}
+
+		if fi.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		path, err = filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+
+		return os.MkdirAll(filepath.Join(cloned, path), fi.Mode().Perm()|0700)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return cloned, nil
+}
@@ -248,13 +248,13 @@ func (fixIdeasInBody) Execute(r io.Reader) ([]byte, error) {
 	// For storing the fixed output
 	b := bytes.NewBuffer(make([]byte, 0, 1024))
 
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		if strings.HasPrefix(scanner.Text(), "## [") {
+	tokenizer := idea.NewTokenizer(r)
+	for tokenizer.Scan() {
+		if tokenizer.Token().Kind == idea.HeaderToken {
 			break
 		}
 
-		if _, err := fmt.Fprintln(b, scanner.Text()); err != nil {
+		if _, err := b.WriteString(tokenizer.Token().Text); err != nil {
 			return nil, err
 		}
 	}
@@ -262,12 +262,16 @@ func (fixIdeasInBody) Execute(r io.Reader) ([]byte, error) {
 	var timestampLine string
 
 	// Look for the timestamp
-	for scanner.Scan() {
-		if _, err := time.Parse(time.UnixDate, scanner.Text()); err == nil {
-			timestampLine = scanner.Text()
+	for tokenizer.Scan() {
+		if tokenizer.Token().Kind == idea.TimestampToken {
+			timestampLine = strings.TrimSpace(tokenizer.Token().Text)
 		}
 	}
 
+	if err := tokenizer.Err(); err != nil {
+		return nil, err
+	}
+
 	// Trim what's in the buffer and append the timestamp line
 	b = bytes.NewBuffer(bytes.TrimSpace(b.Bytes()))
 	if _, err := fmt.Fprintf(b, "\n\n%s\n", timestampLine); err != nil {
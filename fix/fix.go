@@ -1,19 +1,23 @@
 package fix
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
 	"time"
 
+	"github.com/ghthor/journal/config"
 	entryPkg "github.com/ghthor/journal/entry"
 	"github.com/ghthor/journal/git"
 	"github.com/ghthor/journal/idea"
+	initialize "github.com/ghthor/journal/init"
+	"github.com/ghthor/journal/lock"
 )
 
 type entriesByDate []string
@@ -74,6 +78,17 @@ func entriesIn(directory string) (entries []string, err error) {
 	return
 }
 
+// Returns the dates to commit a fix to an entry with.
+// The entry is authored when it was opened.
+func entryDates(filename string) git.Dates {
+	openedAt, err := time.ParseInLocation(entryPkg.FilenameLayout, filepath.Base(filename), time.Local)
+	if err != nil {
+		return git.Dates{}
+	}
+
+	return git.Dates{Author: openedAt}
+}
+
 func mvEntriesIn(directory string, entries []string) (movedEntries []string, commit git.Commitable, err error) {
 	err = os.Mkdir(filepath.Join(directory, "entry"), 0700)
 	if err != nil {
@@ -117,9 +132,8 @@ func mvEntriesIn(directory string, entries []string) (movedEntries []string, com
 	return movedEntries, changes, nil
 }
 
-func lastCommitHashIn(directory string) (string, error) {
-	o, err := git.Command(directory, "rev-parse", "HEAD").Output()
-	return string(bytes.TrimSpace(o)), err
+func lastCommitHashIn(ctx context.Context, repo git.Repository) (string, error) {
+	return repo.RevParse(ctx, "HEAD")
 }
 
 type journalFixCommit struct {
@@ -130,6 +144,12 @@ func (c journalFixCommit) CommitMsg() string {
 	return "journal - fix - " + c.Commitable.CommitMsg()
 }
 
+func (c journalFixCommit) CommitDescription() git.Description {
+	d := c.Commitable.CommitDescription()
+	d.Kind = git.FixCommit
+	return d
+}
+
 type journalFixCommitWithSuffix struct {
 	git.Commitable
 	suffix string
@@ -139,14 +159,30 @@ func (c journalFixCommitWithSuffix) CommitMsg() string {
 	return "journal - fix - " + c.Commitable.CommitMsg() + " - " + c.suffix
 }
 
-func fixCase0(directory string) (refLog []string, err error) {
+func (c journalFixCommitWithSuffix) CommitDescription() git.Description {
+	d := c.Commitable.CommitDescription()
+	d.Kind = git.FixCommit
+	return d
+}
+
+func fixCase0(ctx context.Context, directory string, opts Options) (refLog []string, err error) {
+	cfg, err := config.Load(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := cfg.Repository(directory)
+	if err != nil {
+		return nil, err
+	}
+
 	// Mark the begining of the fix commit log
-	err = git.CommitEmpty(directory, "journal - fix - begin")
+	err = git.CommitEmptyTo(ctx, repo, journalFixCommit{&git.Changes{Dir: directory, Msg: "begin"}})
 	if err != nil {
 		return nil, err
 	}
 
-	beginHash, err := lastCommitHashIn(directory)
+	beginHash, err := lastCommitHashIn(ctx, repo)
 	if err != nil {
 		return nil, err
 	}
@@ -163,12 +199,12 @@ func fixCase0(directory string) (refLog []string, err error) {
 		return nil, err
 	}
 
-	err = git.Commit(journalFixCommit{changes})
+	err = git.CommitTo(ctx, repo, journalFixCommit{changes})
 	if err != nil {
 		return nil, err
 	}
 
-	commitHash, err := lastCommitHashIn(directory)
+	commitHash, err := lastCommitHashIn(ctx, repo)
 	if err != nil {
 		return nil, err
 	}
@@ -185,12 +221,17 @@ func fixCase0(directory string) (refLog []string, err error) {
 		return nil, err
 	}
 
-	err = git.Commit(journalFixCommit{changes})
+	err = ideaStore.SetStatuses(cfg.Statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	err = git.CommitTo(ctx, repo, journalFixCommit{changes})
 	if err != nil {
 		return nil, err
 	}
 
-	commitHash, err = lastCommitHashIn(directory)
+	commitHash, err = lastCommitHashIn(ctx, repo)
 	if err != nil {
 		return nil, err
 	}
@@ -208,41 +249,28 @@ func fixCase0(directory string) (refLog []string, err error) {
 		for scanner.Scan() {
 			newIdea := scanner.Idea()
 
-			// Look for an Idea with the same Name
-			err = filepath.Walk(filepath.Join(directory, "idea"), func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-
-				if info.IsDir() {
-					return nil
-				}
-
-				if info.Name() == "nextid" || info.Name() == "active" {
-					return nil
-				}
+			// Reuse the id of an Idea with the same Name
+			existing, found, err := ideaStore.IdeaNamed(newIdea.Name)
+			if err != nil {
+				return nil, err
+			}
 
-				ideaFile, err := os.OpenFile(path, os.O_RDONLY, 0660)
+			if found {
+				newIdea.Id = existing.Id
+			} else {
+				// Report the Ideas with a similar Name
+				similar, err := ideaStore.SimilarIdeas(newIdea.Name, idea.DefaultSimilarity)
 				if err != nil {
-					return err
+					return nil, err
 				}
-				defer ideaFile.Close()
 
-				scanner := idea.NewIdeaScanner(ideaFile)
-				if !scanner.Scan() {
-					return errors.New(fmt.Sprintf("unexpected file in idea store %s", path))
+				if len(similar) > 0 {
+					fmt.Fprintf(opts.Report, "%s: the idea %q is likely a duplicate of [%d] %s\n",
+						entries[i], newIdea.Name, similar[0].Id, similar[0].Name)
 				}
+			}
 
-				idea := scanner.Idea()
-
-				if newIdea.Name == idea.Name {
-					newIdea.Id = idea.Id
-				}
-
-				return nil
-			})
-
-			changes, err = ideaStore.SaveIdea(newIdea)
+			changes, err = ideaStore.SaveIdea(ctx, newIdea)
 			if err != nil {
 				if err == idea.ErrIdeaNotModified {
 					continue
@@ -251,15 +279,15 @@ func fixCase0(directory string) (refLog []string, err error) {
 				return nil, err
 			}
 
-			err = git.Commit(journalFixCommitWithSuffix{
+			err = git.CommitTo(ctx, repo, cfg.CommitDates(git.WithDates(journalFixCommitWithSuffix{
 				changes,
 				"src:" + entries[i],
-			})
+			}, entryDates(entries[i]))))
 			if err != nil {
 				return nil, err
 			}
 
-			commitHash, err = lastCommitHashIn(directory)
+			commitHash, err = lastCommitHashIn(ctx, repo)
 			if err != nil {
 				return nil, err
 			}
@@ -304,16 +332,17 @@ func fixCase0(directory string) (refLog []string, err error) {
 			changes := commitable.(git.Changes)
 			changes.Dir = directory
 			changes.Add(git.ChangedFile(entryFilename))
+			changes.Dates = entryDates(entryFilename)
 
-			err = git.Commit(journalFixCommitWithSuffix{
+			err = git.CommitTo(ctx, repo, cfg.CommitDates(journalFixCommitWithSuffix{
 				changes,
 				entryFilename,
-			})
+			}))
 			if err != nil {
 				return nil, err
 			}
 
-			commitHash, err = lastCommitHashIn(directory)
+			commitHash, err = lastCommitHashIn(ctx, repo)
 			if err != nil {
 				return nil, err
 			}
@@ -321,13 +350,27 @@ func fixCase0(directory string) (refLog []string, err error) {
 		}
 	}
 
+	// Resolve merges of the idea store with the merge drivers
+	committed, err := fixMergeDrivers(ctx, directory, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if committed {
+		commitHash, err = lastCommitHashIn(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		refLog = append(refLog, commitHash)
+	}
+
 	// Mark the fix completed in the commit log
-	err = git.CommitEmpty(directory, "journal - fix - completed")
+	err = git.CommitEmptyTo(ctx, repo, journalFixCommit{&git.Changes{Dir: directory, Msg: "completed"}})
 	if err != nil {
 		return nil, err
 	}
 
-	completedHash, err := lastCommitHashIn(directory)
+	completedHash, err := lastCommitHashIn(ctx, repo)
 	if err != nil {
 		return nil, err
 	}
@@ -336,39 +379,154 @@ func fixCase0(directory string) (refLog []string, err error) {
 	return
 }
 
+// Registers the merge drivers and commits the assignment
+// of the drivers in .gitattributes if it's missing.
+// Returns false if .gitattributes didn't need to be changed.
+func fixMergeDrivers(ctx context.Context, directory string, repo git.Repository) (committed bool, err error) {
+	err = initialize.RegisterMergeDrivers(ctx, directory)
+	if err != nil {
+		return false, err
+	}
+
+	attributes, err := initialize.WriteGitAttributes(directory)
+	if err != nil || attributes == nil {
+		return false, err
+	}
+
+	err = git.CommitTo(ctx, repo, journalFixCommit{attributes})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Returns true if the journal's storage format isn't the latest
+// version or the version of the format isn't recorded.
 // If returns false, then error may or may not be nil.
 //
 // If returns true, error MUST be nil
 func NeedsFixed(directory string) (bool, error) {
-	entries, err := entriesIn(directory)
+	pending, err := PendingMigrations(directory)
 	if err != nil {
 		return false, err
 	}
 
-	if len(entries) != 0 {
+	if len(pending) != 0 {
 		return true, nil
 	}
 
-	if fi, err := os.Stat(filepath.Join(directory, "entry")); os.IsNotExist(err) {
-		return true, nil
-	} else if !fi.IsDir() {
-		return false, errors.New(fmt.Sprintf("%s filesystem node isn't a directory", filepath.Join(directory, "entry")))
+	version, recorded, err := initialize.FormatVersionOf(directory)
+	if err != nil {
+		return false, err
 	}
 
-	return false, nil
+	return !recorded || version < LatestVersion(), nil
+}
+
+// Options for fixing a journal
+type Options struct {
+	// Ideas that are likely duplicates of an existing idea are reported here
+	Report io.Writer
+}
+
+func Fix(ctx context.Context, directory string) (refLog []string, err error) {
+	return FixWith(ctx, directory, Options{})
 }
 
-func Fix(directory string) (refLog []string, err error) {
-	needsFixed, err := NeedsFixed(directory)
+func FixWith(ctx context.Context, directory string, opts Options) (refLog []string, err error) {
+	if opts.Report == nil {
+		opts.Report = ioutil.Discard
+	}
+
+	l, err := lock.Journal(directory)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	version, err := versionOf(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := cfg.Repository(directory)
 	if err != nil {
 		return nil, err
 	}
 
-	// Drop out early if theres nothing to fix
-	if !needsFixed {
-		return nil, nil
+	// Run each migration from the journal's version to the latest. Each is
+	// detected after the migrations before it have been applied and the
+	// version is recorded once it's applied, so an upgrade that fails
+	// continues from the last version that was recorded.
+	for ; version < LatestVersion(); version++ {
+		m := migrations[version]
+
+		detected, err := m.Detect(directory)
+		if err != nil {
+			return refLog, err
+		}
+
+		if detected {
+			commits, err := m.Apply(ctx, directory, opts)
+			refLog = append(refLog, commits...)
+			if err != nil {
+				return refLog, fmt.Errorf("error migrating to storage format version %d: %v", version+1, err)
+			}
+
+			// The migration may have changed the configuration
+			cfg, err = config.Load(directory)
+			if err != nil {
+				return refLog, err
+			}
+
+			repo, err = cfg.Repository(directory)
+			if err != nil {
+				return refLog, err
+			}
+		}
+
+		commitHash, err := commitVersion(ctx, repo, directory, version+1)
+		if err != nil {
+			return refLog, err
+		}
+		refLog = append(refLog, commitHash)
+	}
+
+	// Journals initialized before the merge drivers existed are missing them
+	committed, err := fixMergeDrivers(ctx, directory, repo)
+	if err != nil {
+		return refLog, err
+	}
+
+	if committed {
+		commitHash, err := lastCommitHashIn(ctx, repo)
+		if err != nil {
+			return refLog, err
+		}
+		refLog = append(refLog, commitHash)
+	}
+
+	return refLog, nil
+}
+
+// Records the version of the journal's storage format,
+// commits it and returns the hash of the commit
+func commitVersion(ctx context.Context, repo git.Repository, directory string, version int) (string, error) {
+	format, err := initialize.WriteFormatVersion(directory, version)
+	if err != nil {
+		return "", err
+	}
+
+	err = git.CommitTo(ctx, repo, journalFixCommit{format})
+	if err != nil {
+		return "", err
 	}
 
-	// Make a blanket assumption that we're dealing with case0
-	return fixCase0(directory)
+	return lastCommitHashIn(ctx, repo)
 }
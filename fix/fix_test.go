@@ -1,20 +1,26 @@
 package fix
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ghthor/journal/fix/case_0_static"
 	"github.com/ghthor/journal/git"
 	"github.com/ghthor/journal/git/gittest"
 	"github.com/ghthor/journal/idea"
+	initialize "github.com/ghthor/journal/init"
 
 	"github.com/ghthor/gospec"
 	. "github.com/ghthor/gospec"
 )
 
 func DescribeAFixableJournal(c gospec.Context) {
+	ctx := context.Background()
+
 	tmpDir := func(prefix string) (directory string, cleanUp func()) {
 		directory, err := ioutil.TempDir("", prefix+"_")
 		c.Assume(err, IsNil)
@@ -34,7 +40,7 @@ func DescribeAFixableJournal(c gospec.Context) {
 		d, _, err := case_0_static.NewIn(baseDir)
 		c.Assume(err, IsNil)
 
-		_, err = Fix(d)
+		_, err = Fix(ctx, d)
 		c.Assume(err, IsNil)
 
 		c.Specify("directory", func() {
@@ -80,6 +86,176 @@ func DescribeAFixableJournal(c gospec.Context) {
 				})
 			})
 		})
+
+		c.Specify("that will have the merge drivers assigned if they're missing", func() {
+			c.Assume(git.Command(d, "rm", "-q", ".gitattributes").Run(), IsNil)
+			c.Assume(git.CommitWithMessage(ctx, d, "unassign the merge drivers"), IsNil)
+
+			refLog, err := Fix(ctx, d)
+			c.Assume(err, IsNil)
+			c.Expect(len(refLog), Equals, 1)
+			c.Expect(git.IsClean(ctx, d), IsNil)
+
+			attributes, err := ioutil.ReadFile(filepath.Join(d, ".gitattributes"))
+			c.Assume(err, IsNil)
+			c.Expect(string(attributes), Equals, "idea/active merge=journal-active\nidea/nextid merge=journal-nextid\n")
+
+			refLog, err = Fix(ctx, d)
+			c.Assume(err, IsNil)
+			c.Expect(len(refLog), Equals, 0)
+		})
+		c.Specify("that records the latest version of the storage format", func() {
+			version, recorded, err := initialize.FormatVersionOf(d)
+			c.Assume(err, IsNil)
+			c.Expect(recorded, IsTrue)
+			c.Expect(version, Equals, LatestVersion())
+			c.Expect(LatestVersion(), Equals, initialize.FormatVersion)
+
+			needsFixed, err := NeedsFixed(d)
+			c.Assume(err, IsNil)
+			c.Expect(needsFixed, IsFalse)
+
+			refLog, err := Fix(ctx, d)
+			c.Assume(err, IsNil)
+			c.Expect(len(refLog), Equals, 0)
+		})
+
+		c.Specify("that won't change in a dry run", func() {
+			plan, err := DryRun(ctx, d, Options{})
+			c.Assume(err, IsNil)
+			c.Expect(len(plan.Commits), Equals, 0)
+			c.Expect(plan.Diff, Equals, "")
+		})
+
+		c.Specify("that will record the version of the storage format if it's missing", func() {
+			c.Assume(git.Command(d, "rm", "-q", initialize.FormatFilename).Run(), IsNil)
+			c.Assume(git.CommitWithMessage(ctx, d, "forget the format version"), IsNil)
+
+			needsFixed, err := NeedsFixed(d)
+			c.Assume(err, IsNil)
+			c.Expect(needsFixed, IsTrue)
+
+			pending, err := PendingMigrations(d)
+			c.Assume(err, IsNil)
+			c.Expect(len(pending), Equals, 0)
+
+			refLog, err := Fix(ctx, d)
+			c.Assume(err, IsNil)
+			c.Expect(len(refLog), Equals, 1)
+			c.Expect(git.IsClean(ctx, d), IsNil)
+
+			version, recorded, err := initialize.FormatVersionOf(d)
+			c.Assume(err, IsNil)
+			c.Expect(recorded, IsTrue)
+			c.Expect(version, Equals, LatestVersion())
+		})
+
+		c.Specify("that will record the version once a migration is applied", func() {
+			c.Assume(git.Command(d, "rm", "-q", initialize.FormatFilename).Run(), IsNil)
+			c.Assume(git.CommitWithMessage(ctx, d, "forget the format version"), IsNil)
+
+			registered := migrations
+			defer func() { migrations = registered }()
+
+			var applyErr error
+			migrations = []Migration{fakeMigration{apply: func(ctx context.Context, directory string) ([]string, error) {
+				if applyErr != nil {
+					return nil, applyErr
+				}
+
+				c.Assume(ioutil.WriteFile(filepath.Join(directory, "upgraded"), nil, 0644), IsNil)
+				c.Assume(git.Command(directory, "add", "upgraded").Run(), IsNil)
+				c.Assume(git.CommitWithMessage(ctx, directory, "upgrade"), IsNil)
+
+				hash, err := git.Command(directory, "rev-parse", "HEAD").Output()
+				c.Assume(err, IsNil)
+				return []string{string(hash[:len(hash)-1])}, nil
+			}}}
+
+			refLog, err := Fix(ctx, d)
+			c.Assume(err, IsNil)
+			c.Expect(len(refLog), Equals, 2)
+			c.Expect(git.IsClean(ctx, d), IsNil)
+
+			subject, err := git.Command(d, "show", "-s", "--format=%s", refLog[1]).Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(subject), Equals, "journal - fix - format version 1\n")
+
+			version, recorded, err := initialize.FormatVersionOf(d)
+			c.Assume(err, IsNil)
+			c.Expect(recorded, IsTrue)
+			c.Expect(version, Equals, 1)
+
+			c.Specify("unless it fails", func() {
+				c.Assume(git.Command(d, "rm", "-q", initialize.FormatFilename).Run(), IsNil)
+				c.Assume(git.CommitWithMessage(ctx, d, "forget the format version"), IsNil)
+
+				applyErr = fmt.Errorf("the migration failed")
+
+				_, err := Fix(ctx, d)
+				c.Expect(err, Not(IsNil))
+
+				_, recorded, err := initialize.FormatVersionOf(d)
+				c.Assume(err, IsNil)
+				c.Expect(recorded, IsFalse)
+
+				needsFixed, err := NeedsFixed(d)
+				c.Assume(err, IsNil)
+				c.Expect(needsFixed, IsTrue)
+			})
+		})
+
+		c.Specify("that won't be fixed if its storage format is newer", func() {
+			c.Assume(ioutil.WriteFile(filepath.Join(d, initialize.FormatFilename), []byte(fmt.Sprintln(LatestVersion()+1)), 0644), IsNil)
+
+			_, err := NeedsFixed(d)
+			c.Expect(err, Not(IsNil))
+
+			_, err = Fix(ctx, d)
+			c.Expect(err, Not(IsNil))
+		})
+	})
+
+	c.Specify("a journal in a project's repository", func() {
+		project, cleanUp := tmpDir("journal_fix_project")
+		defer cleanUp()
+
+		c.Assume(git.Command(project, "init", "-q").Run(), IsNil)
+		c.Assume(ioutil.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n"), 0644), IsNil)
+		c.Assume(git.Command(project, "add", "main.go").Run(), IsNil)
+		c.Assume(git.CommitWithMessage(ctx, project, "a project"), IsNil)
+
+		d := filepath.Join(project, "journal")
+		commitable, err := initialize.Journal(ctx, d)
+		c.Assume(err, IsNil)
+		c.Assume(git.Commit(ctx, commitable), IsNil)
+
+		c.Assume(git.Command(d, "rm", "-q", initialize.FormatFilename).Run(), IsNil)
+		c.Assume(git.CommitWithMessage(ctx, d, "forget the format version"), IsNil)
+
+		// A hook that would be run by the commits fixing the journal
+		hookRan := filepath.Join(project, "hook-ran")
+		hook := filepath.Join(project, ".git", "hooks", "post-commit")
+		c.Assume(ioutil.WriteFile(hook, []byte("#!/bin/sh\ntouch "+hookRan+"\n"), 0755), IsNil)
+
+		c.Specify("can be fixed in a dry run without running its hooks", func() {
+			head, err := git.Command(d, "rev-parse", "HEAD").Output()
+			c.Assume(err, IsNil)
+
+			plan, err := DryRun(ctx, d, Options{})
+			c.Assume(err, IsNil)
+			c.Assume(len(plan.Commits), Equals, 1)
+			c.Expect(plan.Commits[0].Subject(), Equals, "journal - fix - format version 1")
+			c.Expect(strings.Contains(plan.Diff, "b/journal/format"), IsTrue)
+
+			_, err = os.Stat(hookRan)
+			c.Expect(os.IsNotExist(err), IsTrue)
+
+			after, err := git.Command(d, "rev-parse", "HEAD").Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(after), Equals, string(head))
+			c.Expect(git.IsClean(ctx, d), IsNil)
+		})
 	})
 
 	c.Specify("a fixable journal is a", func() {
@@ -89,14 +265,20 @@ func DescribeAFixableJournal(c gospec.Context) {
 		c.Specify("directory", func() {
 			c.Specify("inside a git repository", func() {
 				c.Assume(d, gittest.IsAGitRepository)
-				c.Assume(git.IsClean(d), IsNil)
+				c.Assume(git.IsClean(ctx, d), IsNil)
 
 				// Case 0
 				c.Specify("that contains entries", func() {
 					needsFixed, err := NeedsFixed(d)
 					c.Expect(needsFixed, IsTrue)
 
-					_, err = Fix(d)
+					pending, err := PendingMigrations(d)
+					c.Assume(err, IsNil)
+					c.Assume(len(pending), Equals, 1)
+					c.Expect(pending[0].Version, Equals, 1)
+					c.Expect(pending[0].Describe(), Equals, "move the entries into entry/ and store their ideas in idea/")
+
+					_, err = Fix(ctx, d)
 					c.Expect(err, IsNil)
 
 					needsFixed, err = NeedsFixed(d)
@@ -106,3 +288,14 @@ func DescribeAFixableJournal(c gospec.Context) {
 		})
 	})
 }
+
+// A migration that is always detected and applied by calling apply
+type fakeMigration struct {
+	apply func(ctx context.Context, directory string) ([]string, error)
+}
+
+func (fakeMigration) Detect(string) (bool, error) { return true, nil }
+func (fakeMigration) Describe() string            { return "a fake migration" }
+func (m fakeMigration) Apply(ctx context.Context, directory string, _ Options) ([]string, error) {
+	return m.apply(ctx, directory)
+}
@@ -0,0 +1,135 @@
+package fix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	initialize "github.com/ghthor/journal/init"
+)
+
+// A change to the storage format of a journal. The migrations
+// are kept in order, the migration at index i upgrades a
+// journal from version i of the format to version i+1.
+type Migration interface {
+	// Returns true if the journal in directory is
+	// stored in the format the migration upgrades
+	Detect(directory string) (bool, error)
+
+	// A one line description of what the migration changes
+	Describe() string
+
+	// Upgrades the journal in directory, commits the changes
+	// and returns the hashes of the commits it made
+	Apply(ctx context.Context, directory string, opts Options) (refLog []string, err error)
+}
+
+// The migrations in the order they're run
+var migrations = []Migration{
+	case0Migration{},
+}
+
+// Returns the migrations, oldest first
+func Migrations() []Migration {
+	return append([]Migration(nil), migrations...)
+}
+
+// The version of the storage format a journal is upgraded to,
+// the version a new journal is initialized with
+func LatestVersion() int {
+	return initialize.FormatVersion
+}
+
+func init() {
+	if len(migrations) != initialize.FormatVersion {
+		panic(fmt.Sprintf("%d migrations upgrade a journal to storage format version %d, expected version %d",
+			len(migrations), len(migrations), initialize.FormatVersion))
+	}
+}
+
+// Entries stored in the journal's directory and ideas
+// stored in the entries are moved into `entry/` and an idea
+// directory store. Upgrades a journal to version 1.
+type case0Migration struct{}
+
+func (case0Migration) Detect(directory string) (bool, error) {
+	entries, err := entriesIn(directory)
+	if err != nil {
+		return false, err
+	}
+
+	if len(entries) != 0 {
+		return true, nil
+	}
+
+	if fi, err := os.Stat(filepath.Join(directory, "entry")); os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	} else if !fi.IsDir() {
+		return false, errors.New(fmt.Sprintf("%s filesystem node isn't a directory", filepath.Join(directory, "entry")))
+	}
+
+	return false, nil
+}
+
+func (case0Migration) Describe() string {
+	return "move the entries into entry/ and store their ideas in idea/"
+}
+
+func (case0Migration) Apply(ctx context.Context, directory string, opts Options) ([]string, error) {
+	return fixCase0(ctx, directory, opts)
+}
+
+// A migration and the version it upgrades a journal to
+type PendingMigration struct {
+	Migration
+	Version int
+}
+
+// Returns the version of the storage format recorded in the journal,
+// or an error if it's newer than the latest version
+func versionOf(directory string) (int, error) {
+	version, _, err := initialize.FormatVersionOf(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	if version > LatestVersion() {
+		return 0, fmt.Errorf("the storage format version %d of %s is newer than the latest version %d", version, directory, LatestVersion())
+	}
+
+	return version, nil
+}
+
+// Returns the migrations from the journal's current version to the latest
+// version, oldest first, starting with the first migration the journal is
+// detected to need. A migration can only be detected once the migrations
+// before it have been applied, so the migrations after the first are
+// returned without being detected. A journal without a version recorded
+// is detected from the first migration.
+func PendingMigrations(directory string) ([]PendingMigration, error) {
+	version, err := versionOf(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range migrations[version:] {
+		detected, err := m.Detect(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		if detected {
+			pending := make([]PendingMigration, 0, len(migrations)-version-i)
+			for j, m := range migrations[version+i:] {
+				pending = append(pending, PendingMigration{m, version + i + j + 1})
+			}
+			return pending, nil
+		}
+	}
+
+	return nil, nil
+}
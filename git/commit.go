@@ -1,6 +1,14 @@
-// A wrapper using "os/exec" for executing git commands
+// A wrapper for the git repository a journal is stored in.
+// A Repository can be accessed by executing the git binary
+// or with go-git, which doesn't require git to be installed.
 package git
 
+import (
+	"context"
+	"path/filepath"
+	"time"
+)
+
 type CommitableChange interface {
 	Filepath() string
 }
@@ -9,8 +17,29 @@ type Commitable interface {
 	WorkingDirectory() string
 	Changes() []CommitableChange
 	CommitMsg() string
+	CommitDates() Dates
+	CommitDescription() Description
+}
+
+// The dates a commit is made with. A date
+// that is the zero time is the time of the commit.
+type Dates struct {
+	Author    time.Time
+	Committer time.Time
+}
+
+// Returns the commitable with its dates replaced by dates
+func WithDates(c Commitable, dates Dates) Commitable {
+	return datedCommitable{c, dates}
 }
 
+type datedCommitable struct {
+	Commitable
+	dates Dates
+}
+
+func (c datedCommitable) CommitDates() Dates { return c.dates }
+
 // An convenient implementation of the CommitableChange interface
 type ChangedFile string
 
@@ -19,8 +48,12 @@ func (filename ChangedFile) Filepath() string { return string(filename) }
 // An convenient implementation of the Commitable interface
 type Changes struct {
 	// The `git` working directory
-	Dir string
-	Msg string
+	Dir   string
+	Msg   string
+	Dates Dates
+
+	// Describes the commit to the template that renders its message
+	Description Description
 
 	changes []CommitableChange
 }
@@ -33,8 +66,8 @@ func (c *Changes) Add(change CommitableChange) {
 	c.changes = append(c.changes, change)
 }
 
-func (c *Changes) Commit() error {
-	return Commit(c)
+func (c *Changes) Commit(ctx context.Context) error {
+	return Commit(ctx, c)
 }
 
 // implement Commitable Interface
@@ -42,18 +75,60 @@ func (c *Changes) Commit() error {
 func (c Changes) WorkingDirectory() string    { return c.Dir }
 func (c Changes) Changes() []CommitableChange { return c.changes }
 func (c Changes) CommitMsg() string           { return c.Msg }
+func (c Changes) CommitDates() Dates          { return c.Dates }
+func (c Changes) CommitDescription() Description {
+	return c.Description
+}
+
+// Combines the changes of several commitables into
+// a single commitable that is committed with msg. The working
+// directory, dates and description are those of the first commitable.
+func Combine(msg string, commitables ...Commitable) *Changes {
+	combined := &Changes{Msg: msg}
+	if len(commitables) > 0 {
+		combined.Dir = commitables[0].WorkingDirectory()
+		combined.Dates = commitables[0].CommitDates()
+		combined.Description = commitables[0].CommitDescription()
+	}
+
+	isAdded := make(map[string]bool, len(commitables))
+	for _, c := range commitables {
+		for _, change := range c.Changes() {
+			path := change.Filepath()
+			if !filepath.IsAbs(path) {
+				var err error
+				path, err = filepath.Abs(filepath.Join(c.WorkingDirectory(), path))
+				if err != nil {
+					// The path is still correct relative to the process's working directory
+					path = filepath.Join(c.WorkingDirectory(), path)
+				}
+			}
+
+			if !isAdded[path] {
+				isAdded[path] = true
+				combined.Add(ChangedFile(path))
+			}
+		}
+	}
+
+	return combined
+}
 
 // Execute `git add` for all Changes()'s
-// then execute `git commit` with CommitMsg()
-func Commit(c Commitable) error {
+// then execute `git commit` with CommitMsg().
+// Only the Changes() are committed.
+func Commit(ctx context.Context, c Commitable) error {
 	d := c.WorkingDirectory()
 
+	args := []string{"commit", "--only", "-m", c.CommitMsg(), "--"}
 	for _, change := range c.Changes() {
-		err := AddFilepath(d, change.Filepath())
+		err := AddFilepath(ctx, d, change.Filepath())
 		if err != nil {
 			return err
 		}
+
+		args = append(args, change.Filepath())
 	}
 
-	return CommitWithMessage(d, c.CommitMsg())
+	return commitWithDates(ctx, d, args, c.CommitDates())
 }
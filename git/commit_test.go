@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"github.com/ghthor/gospec"
@@ -25,10 +26,12 @@ func init() {
 }
 
 func DescribeCommit(c gospec.Context) {
+	ctx := context.Background()
+
 	newChangesIn := func(d string) *Changes {
 		d, err := ioutil.TempDir("_test/", d+"_")
 		c.Assume(err, IsNil)
-		c.Assume(Init(d), IsNil)
+		c.Assume(Init(ctx, d), IsNil)
 		return NewChangesIn(d)
 	}
 
@@ -47,7 +50,7 @@ func DescribeCommit(c gospec.Context) {
 			changes = append(changes, ChangedFile(filename))
 		}
 
-		c.Assume(IsClean(wd), Not(IsNil))
+		c.Assume(IsClean(ctx, wd), Not(IsNil))
 		return
 	}
 
@@ -72,8 +75,8 @@ func DescribeCommit(c gospec.Context) {
 `)
 
 			changes.Msg = "Test Commit"
-			c.Expect(changes.Commit(), IsNil)
-			c.Expect(IsClean(changes.WorkingDirectory()), IsNil)
+			c.Expect(changes.Commit(ctx), IsNil)
+			c.Expect(IsClean(ctx, changes.WorkingDirectory()), IsNil)
 
 			o, err = Command(changes.WorkingDirectory(), "show", "--no-color", "--pretty=format:\"%s%b\"").Output()
 			c.Assume(err, IsNil)
@@ -96,5 +99,33 @@ index 0000000..6a4a926
 +file 1 data
 `)
 		})
+
+		c.Specify("can be combined with other changes", func() {
+			changes := newChangesIn("changes_combine_test")
+			d := changes.WorkingDirectory()
+			c.Assume(os.Mkdir(filepath.Join(d, "idea"), 0755), IsNil)
+
+			ideaChanges := NewChangesIn(filepath.Join(d, "idea"))
+			for _, change := range makeSomeChangesIn(ideaChanges.WorkingDirectory(), []string{"an idea\n"}) {
+				ideaChanges.Add(change)
+			}
+
+			for _, change := range makeSomeChangesIn(d, []string{"an entry\n"}) {
+				changes.Add(change)
+			}
+			changes.Add(ChangedFile("idea"))
+
+			combined := Combine("Combined Commit", changes, ideaChanges)
+			c.Expect(combined.WorkingDirectory(), Equals, d)
+			c.Expect(combined.CommitMsg(), Equals, "Combined Commit")
+			c.Expect(len(combined.Changes()), Equals, 3)
+
+			c.Expect(combined.Commit(ctx), IsNil)
+			c.Expect(IsClean(ctx, d), IsNil)
+
+			o, err := Command(d, "log", "--format=%s").Output()
+			c.Assume(err, IsNil)
+			c.Expect(string(o), Equals, "Combined Commit\n")
+		})
 	})
 }
@@ -0,0 +1,145 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Returned when executing git fails
+type Error struct {
+	// The arguments git was executed with
+	Args []string
+
+	// The exit code of git, -1 if it didn't exit
+	ExitCode int
+
+	// The output of git
+	Stdout, Stderr []byte
+}
+
+// Returns the name of the git command that was executed
+func (e Error) Command() string {
+	for i := 0; i < len(e.Args); i++ {
+		switch {
+		case e.Args[i] == "-c" || e.Args[i] == "-C":
+			// The option's value follows it
+			i++
+		case !strings.HasPrefix(e.Args[i], "-"):
+			return e.Args[i]
+		}
+	}
+	return ""
+}
+
+// Returns the error message git printed. Some commands,
+// like `git commit`, print why they failed to stdout.
+func (e Error) Output() string {
+	if output := bytes.TrimSpace(e.Stderr); len(output) != 0 {
+		return string(output)
+	}
+	return string(bytes.TrimSpace(e.Stdout))
+}
+
+func (e Error) Error() string {
+	msg := fmt.Sprintf("error during `git %s`: exit status %d", e.Command(), e.ExitCode)
+	if output := e.Output(); len(output) != 0 {
+		msg += "\n" + output
+	}
+	return msg
+}
+
+func IsError(err error) bool {
+	_, ok := err.(Error)
+	return ok
+}
+
+// Returned when there aren't any changes to commit
+var ErrNothingToCommit = errors.New("nothing to commit")
+
+// Returned when a directory isn't within a git repository
+type NotARepoError struct {
+	Directory string
+}
+
+func (e NotARepoError) Error() string {
+	return fmt.Sprintf("%s is not inside a git repository", e.Directory)
+}
+
+// Returns true if a commit failed because nothing was changed
+func IsNothingToCommit(err error) bool {
+	if err == ErrNothingToCommit {
+		return true
+	}
+
+	e, ok := err.(Error)
+	if !ok || e.Command() != "commit" {
+		return false
+	}
+
+	output := e.Output()
+	return strings.Contains(output, "nothing to commit") ||
+		strings.Contains(output, "nothing added to commit") ||
+		strings.Contains(output, "no changes added to commit")
+}
+
+// Returns true if git failed because it wasn't executed in a repository
+func IsNotARepo(err error) bool {
+	if _, ok := err.(NotARepoError); ok {
+		return true
+	}
+
+	e, ok := err.(Error)
+	return ok && strings.Contains(e.Output(), "not a git repository")
+}
+
+// Returns true if git failed because another git
+// process is changing the repository
+func IsLocked(err error) bool {
+	e, ok := err.(Error)
+	return ok && strings.Contains(e.Output(), ".lock': File exists")
+}
+
+// Returns true if a push was rejected because the
+// remote has commits that haven't been fetched
+func IsPushRejected(err error) bool {
+	e, ok := err.(Error)
+	if !ok || e.Command() != "push" {
+		return false
+	}
+
+	output := e.Output()
+	return strings.Contains(output, "(fetch first)") ||
+		strings.Contains(output, "(non-fast-forward)")
+}
+
+// Execute a command constructed by Command and return its stdout.
+// If git exits with an error the error is an Error.
+func Run(c *exec.Cmd) ([]byte, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+
+	var stdout, stderr bytes.Buffer
+	c.Stdout, c.Stderr = &stdout, &stderr
+
+	err := c.Run()
+	if err == exec.ErrWaitDelay {
+		// git exited successfully but a process it started,
+		// like a remote helper, is holding its output open
+		err = nil
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return stdout.Bytes(), Error{
+			Args:     c.Args[1:],
+			ExitCode: exitErr.ExitCode(),
+			Stdout:   stdout.Bytes(),
+			Stderr:   stderr.Bytes(),
+		}
+	}
+
+	return stdout.Bytes(), err
+}
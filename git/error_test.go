@@ -0,0 +1,88 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeError(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("a git error", func() {
+		d, err := ioutil.TempDir("", "git_error_test")
+		c.Assume(err, IsNil)
+
+		defer func(dir string) {
+			c.Expect(os.RemoveAll(dir), IsNil)
+		}(d)
+
+		c.Specify("will have the command, exit code and output", func() {
+			_, err := Run(Command(d, "-c", "core.quotepath=off", "status"))
+			c.Assume(IsError(err), IsTrue)
+
+			e := err.(Error)
+			c.Expect(fmt.Sprint(e.Args), Equals, "[-c core.quotepath=off status]")
+			c.Expect(e.Command(), Equals, "status")
+			c.Expect(e.ExitCode, Equals, 128)
+			c.Expect(len(e.Stderr) > 0, IsTrue)
+			c.Expect(err.Error(), Equals, "error during `git status`: exit status 128\n"+e.Output())
+		})
+
+		c.Specify("can be a directory that isn't a repository", func() {
+			c.Expect(IsNotARepo(IsClean(ctx, d)), IsTrue)
+
+			for _, backend := range []string{ExecBackend, GoGitBackend} {
+				_, err := Open(d, backend)
+				c.Expect(IsNotARepo(err), IsTrue)
+			}
+		})
+
+		c.Specify("in a repository", func() {
+			c.Assume(Init(ctx, d), IsNil)
+			c.Assume(ioutil.WriteFile(filepath.Join(d, "file"), []byte("a file\n"), 0600), IsNil)
+
+			c.Specify("can be nothing to commit", func() {
+				c.Expect(IsNothingToCommit(CommitWithMessage(ctx, d, "nothing")), IsTrue)
+				c.Expect(IsNothingToCommit(AddFilepath(ctx, d, "missing")), IsFalse)
+
+				for _, backend := range []string{ExecBackend, GoGitBackend} {
+					r, err := Open(d, backend)
+					c.Assume(err, IsNil)
+					c.Expect(IsNothingToCommit(r.Commit(ctx, "nothing")), IsTrue)
+				}
+			})
+
+			c.Specify("can be a repository that is locked", func() {
+				gitDir, err := FindGitDir(d)
+				c.Assume(err, IsNil)
+				c.Assume(ioutil.WriteFile(filepath.Join(gitDir, "index.lock"), nil, 0600), IsNil)
+
+				err = AddFilepath(ctx, d, "file")
+				c.Expect(IsLocked(err), IsTrue)
+				c.Expect(IsNotARepo(err), IsFalse)
+			})
+
+			c.Specify("can be a push that was rejected", func() {
+				remote := filepath.Join(d, "remote.git")
+				c.Assume(Command(d, "init", "-q", "--bare", remote).Run(), IsNil)
+
+				other := filepath.Join(d, "other")
+				c.Assume(Init(ctx, other), IsNil)
+				c.Assume(CommitEmpty(ctx, other, "another history"), IsNil)
+				c.Assume(Push(ctx, other, remote, "HEAD:refs/heads/journal"), IsNil)
+
+				c.Assume(AddFilepath(ctx, d, "file"), IsNil)
+				c.Assume(CommitWithMessage(ctx, d, "a commit"), IsNil)
+
+				err := Push(ctx, d, remote, "HEAD:refs/heads/journal")
+				c.Expect(IsPushRejected(err), IsTrue)
+			})
+		})
+	})
+}
@@ -1,45 +1,105 @@
 package git
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
+	"time"
 )
 
-var gitPath string
+// Returned when the exec backend is used and git isn't installed
+var ErrGitNotInstalled = errors.New("git must be installed to use the exec backend")
 
-func init() {
-	var err error
-	gitPath, err = exec.LookPath("git")
+func lookPath() (string, error) {
+	path, err := exec.LookPath("git")
 	if err != nil {
-		log.Fatal("git must be installed")
+		return "", ErrGitNotInstalled
 	}
+	return path, nil
 }
 
-// Construct an *exec.Cmd for `git {args}` with a workingDirectory
+// How long git is given to exit after it's interrupted before it's killed
+const interruptDelay = time.Second
+
+// Construct an *exec.Cmd for `git {args}` with a workingDirectory.
+// If git isn't installed running the command will return ErrGitNotInstalled.
 func Command(workingDirectory string, args ...string) *exec.Cmd {
-	c := exec.Command(gitPath, args...)
+	return CommandContext(context.Background(), workingDirectory, args...)
+}
+
+// Construct an *exec.Cmd for `git {args}` that is interrupted when ctx
+// is done. git removes its lock files when it's interrupted, it's
+// only killed if it hasn't exited a second later.
+func CommandContext(ctx context.Context, workingDirectory string, args ...string) *exec.Cmd {
+	gitPath, err := lookPath()
+
+	c := exec.CommandContext(ctx, gitPath, args...)
+	if err != nil {
+		c.Err = err
+	}
 	c.Dir = workingDirectory
+
+	c.Cancel = func() error {
+		if err := c.Process.Signal(os.Interrupt); err != nil {
+			return c.Process.Kill()
+		}
+		return nil
+	}
+	c.WaitDelay = interruptDelay
+
 	return c
 }
 
+// Execute `git {args}` in workingDirectory and return its stdout.
+// If ctx is done before git exits the error is the cause of ctx being done.
+func Exec(ctx context.Context, workingDirectory string, args ...string) ([]byte, error) {
+	return run(ctx, CommandContext(ctx, workingDirectory, args...))
+}
+
+// Run a command constructed by CommandContext with ctx
+func run(ctx context.Context, c *exec.Cmd) ([]byte, error) {
+	o, err := Run(c)
+	if err != nil && ctx.Err() != nil {
+		return o, context.Cause(ctx)
+	}
+	return o, err
+}
+
+// Returns the cause of ctx being done, nil if it isn't
+func canceled(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
+
 // Execute `git init {directory}` in the current workingDirectory
-func Init(directory string) error {
+func Init(ctx context.Context, directory string) error {
 	wd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
-	return Command(wd, "init", directory).Run()
+	_, err = Exec(ctx, wd, "init", directory)
+	return err
 }
 
-// Execute `git status -s` in directory
-// If there is output, the directory has is dirty
-func IsClean(directory string) error {
-	c := Command(directory, "status", "-s")
+// Execute `git config {name} {value}` in workingDirectory.
+// Returns ErrGitNotInstalled if git can't be found.
+func SetConfig(ctx context.Context, workingDirectory string, name, value string) error {
+	_, err := Exec(ctx, workingDirectory, "config", name, value)
+	return err
+}
 
-	o, err := c.Output()
+// Execute `git status -s` in directory
+// If there is output, the directory has is dirty.
+// Changes outside of directory are ignored.
+func IsClean(ctx context.Context, directory string) error {
+	o, err := Exec(ctx, directory, "status", "-s", "--", ".")
 	if err != nil {
 		return err
 	}
@@ -51,26 +111,287 @@ func IsClean(directory string) error {
 	return nil
 }
 
-// Execute `git add --all {filepath}` in workingDirectory
-func AddFilepath(workingDirectory string, filepath string) error {
-	o, err := Command(workingDirectory, "add", "--all", filepath).CombinedOutput()
+// Execute `git add --all {filepath}` in workingDirectory.
+// Only the changes to filepath are staged.
+func AddFilepath(ctx context.Context, workingDirectory string, filepath string) error {
+	_, err := Exec(ctx, workingDirectory, "add", "--all", filepath)
+	return err
+}
+
+// Execute `git commit -m {msg}` in workingDirectory.
+// Everything that is staged is committed.
+func CommitWithMessage(ctx context.Context, workingDirectory string, msg string) error {
+	_, err := Exec(ctx, workingDirectory, "commit", "-m", msg)
+	return err
+}
+
+// Formats a date the way git stores it
+func gitDate(t time.Time) string {
+	return fmt.Sprintf("%d %s", t.Unix(), t.Format("-0700"))
+}
+
+// Execute `git {args}` in workingDirectory
+// with the author and committer dates that are set
+func commitWithDates(ctx context.Context, workingDirectory string, args []string, dates Dates) error {
+	c := CommandContext(ctx, workingDirectory, args...)
+
+	if !dates.Author.IsZero() || !dates.Committer.IsZero() {
+		c.Env = os.Environ()
+		if !dates.Author.IsZero() {
+			c.Env = append(c.Env, "GIT_AUTHOR_DATE="+gitDate(dates.Author))
+		}
+		if !dates.Committer.IsZero() {
+			c.Env = append(c.Env, "GIT_COMMITTER_DATE="+gitDate(dates.Committer))
+		}
+	}
+
+	_, err := run(ctx, c)
+	return err
+}
+
+// Execute `git commit --allow-empty -m {msg}` in workingDirectory.
+func CommitEmpty(ctx context.Context, workingDirectory string, msg string) error {
+	_, err := Exec(ctx, workingDirectory, "commit", "--allow-empty", "-m", msg)
+	return err
+}
+
+// A Repository that executes the `git` binary
+type execRepository struct {
+	dir      string
+	signing  Signing
+	messages MsgTemplates
+}
+
+// Open the repository containing directory using the exec backend.
+// Returns ErrGitNotInstalled if git can't be found.
+func OpenExec(directory string) (Repository, error) {
+	if _, err := lookPath(); err != nil {
+		return nil, err
+	}
+
+	if _, err := FindGitDir(directory); err != nil {
+		return nil, err
+	}
+
+	return execRepository{dir: directory}, nil
+}
+
+func (r execRepository) WorkingDirectory() string { return r.dir }
+
+func (r execRepository) Add(ctx context.Context, path string) error {
+	return AddFilepath(ctx, r.dir, path)
+}
+
+// Returns the arguments for `git commit` signed if signing is enabled
+func (r execRepository) commitArgs(args ...string) []string {
+	if !r.signing.IsEnabled() {
+		return append([]string{"commit"}, args...)
+	}
+
+	signed := append(r.signing.gitConfig(), "commit", "-S")
+	return append(signed, args...)
+}
+
+// Returns the paths in the working directory that differ between
+// rev and the index, relative to the working directory. If rev is
+// empty the index is compared with HEAD. If worktree is true the
+// paths that differ between rev and the working tree are included.
+func (r execRepository) changedPaths(ctx context.Context, rev string, worktree bool) ([]string, error) {
+	diffs := [][]string{{"diff", "--cached"}}
+	if worktree {
+		diffs = append(diffs, []string{"diff"})
+	}
+
+	isChanged := make(map[string]bool)
+	paths := make([]string, 0, 4)
+
+	for _, args := range diffs {
+		args = append(args, "--name-only", "-z", "--no-renames", "--relative")
+		if len(rev) != 0 {
+			args = append(args, rev)
+		}
+		args = append(args, "--", ".")
+
+		o, err := Exec(ctx, r.dir, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range strings.Split(string(o), "\x00") {
+			if len(path) != 0 && !isChanged[path] {
+				isChanged[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// Commits only the changes staged in the working directory.
+// Changes staged outside of it are left staged.
+func (r execRepository) commit(ctx context.Context, msg string, dates Dates, allowEmpty bool) error {
+	paths, err := r.changedPaths(ctx, "", false)
 	if err != nil {
-		return errors.New(fmt.Sprintf("error during `git add`: %s\n%s", err.Error(), string(o)))
+		return err
 	}
-	return nil
+
+	if len(paths) == 0 && !allowEmpty {
+		return ErrNothingToCommit
+	}
+
+	args := []string{"--only"}
+	if allowEmpty {
+		args = append(args, "--allow-empty")
+	}
+
+	args = append(args, "-m", msg, "--")
+	args = append(args, paths...)
+
+	return commitWithDates(ctx, r.dir, r.commitArgs(args...), dates)
+}
+
+func (r execRepository) Commit(ctx context.Context, msg string) error {
+	return r.commit(ctx, msg, Dates{}, false)
+}
+
+func (r execRepository) CommitWithDates(ctx context.Context, msg string, dates Dates) error {
+	return r.commit(ctx, msg, dates, false)
 }
 
-// Execute `git commit -m {msg}` in workingDirectory
-func CommitWithMessage(workingDirectory string, msg string) error {
-	o, err := Command(workingDirectory, "commit", "-m", msg).CombinedOutput()
+func (r execRepository) CommitEmpty(ctx context.Context, msg string) error {
+	return r.commit(ctx, msg, Dates{}, true)
+}
+
+func (r execRepository) CommitMsg(c Commitable) (string, error) {
+	return r.messages.Render(c)
+}
+
+func (r execRepository) Status(ctx context.Context) ([]FileStatus, error) {
+	o, err := Exec(ctx, r.dir, "status", "--porcelain", "-z", "--untracked-files=all", "--", ".")
 	if err != nil {
-		return errors.New(fmt.Sprintf("error during `git commit`: %s\n%s", err.Error(), string(o)))
+		return nil, err
 	}
 
-	return nil
+	status := make([]FileStatus, 0, 4)
+
+	fields := strings.Split(string(o), "\x00")
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if len(field) < 4 {
+			continue
+		}
+
+		status = append(status, FileStatus{field[3:], field[0], field[1]})
+
+		// A rename or copy is followed by the original path
+		if field[0] == 'R' || field[0] == 'C' {
+			i++
+		}
+	}
+
+	sort.Sort(statusByPath(status))
+
+	return status, nil
 }
 
-// Execute `git commit --allow-empty -m {msg}` in workingDirectory.
-func CommitEmpty(workingDirectory string, msg string) error {
-	return Command(workingDirectory, "commit", "--allow-empty", "-m", msg).Run()
+func (r execRepository) RevParse(ctx context.Context, rev string) (string, error) {
+	o, err := Exec(ctx, r.dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("unknown revision: %s", rev)
+	}
+
+	return string(bytes.TrimSpace(o)), nil
+}
+
+func (r execRepository) Log(ctx context.Context, rev string, max int) ([]LogEntry, error) {
+	commits, err := Log(ctx, r.dir, LogOptions{Range: rev, Max: max})
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+
+	entries := make([]LogEntry, 0, 8)
+	for commits.Scan() {
+		entries = append(entries, *commits.Entry())
+	}
+
+	return entries, commits.Err()
+}
+
+// Runs `git diff` between the commits, limited to the working directory
+func (r execRepository) Diff(ctx context.Context, from, to string) (string, error) {
+	o, err := Exec(ctx, r.dir, "diff", "--find-renames", "--no-color", "--no-ext-diff",
+		"--src-prefix=a/", "--dst-prefix=b/", from, to, "--", ".")
+	if err != nil {
+		return "", err
+	}
+
+	return string(o), nil
 }
+
+// Moves the branch to rev then restores the files in the working
+// directory from rev. Files outside of it are left untouched.
+func (r execRepository) Reset(ctx context.Context, rev string, mode ResetMode) error {
+	paths, err := r.changedPaths(ctx, rev, mode == HardReset)
+	if err != nil {
+		return err
+	}
+
+	_, err = Exec(ctx, r.dir, "reset", "-q", "--soft", rev)
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	args := []string{"restore", "--source=" + rev, "--staged"}
+	if mode == HardReset {
+		args = append(args, "--worktree")
+	}
+
+	args = append(args, "--")
+	args = append(args, paths...)
+
+	_, err = Exec(ctx, r.dir, args...)
+	return err
+}
+
+func (r execRepository) Verify(ctx context.Context, rev string, path string) ([]Verification, error) {
+	args := append(r.signing.gitConfig(), "log", "--format=%H%x1f%G?%x1f%s%x1e", rev, "--")
+	if len(path) != 0 {
+		args = append(args, path)
+	}
+
+	o, err := Exec(ctx, r.dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications := make([]Verification, 0, 8)
+	for _, record := range strings.Split(string(o), "\x1e") {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), "\x1f")
+		if len(fields) != 3 || len(fields[1]) != 1 {
+			continue
+		}
+
+		verifications = append(verifications, Verification{
+			Hash:    fields[0],
+			Subject: fields[2],
+			Status:  SignatureStatus(fields[1][0]),
+		})
+	}
+
+	return verifications, nil
+}
+
+type statusByPath []FileStatus
+
+func (s statusByPath) Len() int           { return len(s) }
+func (s statusByPath) Less(i, j int) bool { return s[i].Path < s[j].Path }
+func (s statusByPath) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"context"
 	"github.com/ghthor/gospec"
 	. "github.com/ghthor/gospec"
 	. "github.com/ghthor/journal/git/gittest"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 )
 
 func TestIntegrationSpecs(t *testing.T) {
@@ -15,11 +17,18 @@ func TestIntegrationSpecs(t *testing.T) {
 
 	r.AddSpec(DescribeGitIntegration)
 	r.AddSpec(DescribeCommit)
+	r.AddSpec(DescribeRepository)
+	r.AddSpec(DescribeLog)
+	r.AddSpec(DescribeError)
+	r.AddSpec(DescribeMsgTemplates)
+	r.AddSpec(DescribeTrailers)
 
 	gospec.MainGoTest(r, t)
 }
 
 func DescribeGitIntegration(c gospec.Context) {
+	ctx := context.Background()
+
 	c.Specify("a git repository will be created", func() {
 		d, err := ioutil.TempDir("", "git_integration_test")
 		c.Assume(err, IsNil)
@@ -29,30 +38,30 @@ func DescribeGitIntegration(c gospec.Context) {
 		}(d)
 
 		d = path.Join(d, "a_git_repo")
-		c.Expect(Init(d), IsNil)
+		c.Expect(Init(ctx, d), IsNil)
 		c.Expect(d, IsAGitRepository)
 
 		c.Specify("and will be clean", func() {
-			c.Expect(IsClean(d), IsNil)
+			c.Expect(IsClean(ctx, d), IsNil)
 		})
 
 		testFile := path.Join(d, "test_file")
 		c.Assume(ioutil.WriteFile(testFile, []byte("some data\n"), 0666), IsNil)
 
 		c.Specify("and will be dirty", func() {
-			c.Expect(IsClean(d).Error(), Equals, "directory is dirty")
+			c.Expect(IsClean(ctx, d).Error(), Equals, "directory is dirty")
 		})
 
 		c.Specify("and will add a file", func() {
-			c.Expect(AddFilepath(d, testFile), IsNil)
+			c.Expect(AddFilepath(ctx, d, testFile), IsNil)
 			o, err := Command(d, "status", "-s").Output()
 			c.Assume(err, IsNil)
 			c.Expect(string(o), Equals, "A  test_file\n")
 		})
 
 		c.Specify("and will commit all staged changes", func() {
-			c.Assume(AddFilepath(d, testFile), IsNil)
-			c.Expect(CommitWithMessage(d, "a commit msg"), IsNil)
+			c.Assume(AddFilepath(ctx, d, testFile), IsNil)
+			c.Expect(CommitWithMessage(ctx, d, "a commit msg"), IsNil)
 
 			o, err := Command(d, "show", "--no-color", "--pretty=format:\"%s%b\"").Output()
 			c.Assume(err, IsNil)
@@ -68,8 +77,24 @@ index 0000000..4268632
 `)
 		})
 
+		c.Specify("and will interrupt git when the context is canceled", func() {
+			canceled, cancel := context.WithCancel(ctx)
+
+			// git waits for the object on stdin until it's interrupted
+			cmd := CommandContext(canceled, d, "hash-object", "-w", "--stdin")
+			stdin, err := cmd.StdinPipe()
+			c.Assume(err, IsNil)
+			defer stdin.Close()
+
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			_, err = run(canceled, cmd)
+			c.Expect(err, Equals, context.Canceled)
+			c.Expect(cmd.ProcessState.Exited(), IsFalse)
+		})
+
 		c.Specify("and will create an empty commit with message", func() {
-			c.Expect(CommitEmpty(d, "an empty commit"), IsNil)
+			c.Expect(CommitEmpty(ctx, d, "an empty commit"), IsNil)
 
 			o, err := Command(d, "show", "--no-color", "--pretty=format:\"%s%b\"").Output()
 			c.Assume(err, IsNil)
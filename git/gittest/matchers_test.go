@@ -1,6 +1,7 @@
 package gittest
 
 import (
+	"context"
 	"github.com/ghthor/gospec"
 	. "github.com/ghthor/gospec"
 	"github.com/ghthor/journal/git"
@@ -19,13 +20,15 @@ func TestUnitSpecs(t *testing.T) {
 }
 
 func DescribeMatchers(c gospec.Context) {
+	ctx := context.Background()
+
 	c.Specify("a directory", func() {
 		d, err := ioutil.TempDir("", "gittest_")
 		c.Assume(err, IsNil)
 
 		c.Assume(d, Not(IsAGitRepository))
 		c.Assume(d, Not(IsInsideAGitRepository))
-		c.Assume(git.Init(d), IsNil)
+		c.Assume(git.Init(ctx, d), IsNil)
 
 		defer func() {
 			c.Assume(os.RemoveAll(d), IsNil)
@@ -0,0 +1,614 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// A Repository implemented using go-git
+type goGitRepository struct {
+	dir  string
+	root string
+
+	repo     *gogit.Repository
+	signing  Signing
+	messages MsgTemplates
+}
+
+func newGoGitRepository(directory string, repo *gogit.Repository) (Repository, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	return goGitRepository{dir: directory, root: wt.Filesystem.Root(), repo: repo}, nil
+}
+
+// Open the repository containing directory using the go-git backend
+func OpenGoGit(directory string) (Repository, error) {
+	repo, err := gogit.PlainOpenWithOptions(directory, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		if err == gogit.ErrRepositoryNotExists {
+			return nil, NotARepoError{directory}
+		}
+		return nil, err
+	}
+
+	return newGoGitRepository(directory, repo)
+}
+
+// Create a repository in directory using the go-git backend
+func InitGoGit(directory string) (Repository, error) {
+	repo, err := gogit.PlainInit(directory, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGoGitRepository(directory, repo)
+}
+
+func (r goGitRepository) WorkingDirectory() string { return r.dir }
+
+// Returns the path relative to the root of the repository
+func (r goGitRepository) relative(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.dir, path)
+	}
+
+	// The root may be reached through a symlink
+	root, err := filepath.EvalSymlinks(r.root)
+	if err != nil {
+		return "", err
+	}
+
+	// The directory of a removed file may not exist
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		dir = filepath.Dir(path)
+	}
+
+	rel, err := filepath.Rel(root, filepath.Join(dir, filepath.Base(path)))
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside of the repository at %s", path, r.root)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// Returns the working directory relative to the root
+// of the repository, empty if it's the root
+func (r goGitRepository) scope() (string, error) {
+	rel, err := r.relative(r.dir)
+	if err != nil || rel == "." {
+		return "", err
+	}
+
+	return rel, nil
+}
+
+// Returns true if the path relative to the root is inside the scope
+func inScope(scope, path string) bool {
+	return len(scope) == 0 || path == scope || strings.HasPrefix(path, scope+"/")
+}
+
+// Calls fn with the path of every file in the tree of the commit
+func (r goGitRepository) walkCommit(hash plumbing.Hash, fn func(path string, entry object.TreeEntry)) error {
+	commit, err := r.repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	for {
+		path, entry, err := walker.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if entry.Mode != filemode.Dir {
+			fn(path, entry)
+		}
+	}
+}
+
+// Replaces the changes staged outside of the scope with HEAD so
+// they aren't committed. Returns the index with the staged changes.
+func (r goGitRepository) scopeIndex(scope string) (staged *index.Index, err error) {
+	staged, err = r.repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := &index.Index{Version: staged.Version}
+	for _, e := range staged.Entries {
+		if inScope(scope, e.Name) {
+			scoped.Entries = append(scoped.Entries, e)
+		}
+	}
+
+	head, err := r.repo.Head()
+	switch {
+	case err == nil:
+		err = r.walkCommit(head.Hash(), func(path string, entry object.TreeEntry) {
+			if !inScope(scope, path) {
+				scoped.Entries = append(scoped.Entries, &index.Entry{Name: path, Hash: entry.Hash, Mode: entry.Mode})
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+
+	case err != plumbing.ErrReferenceNotFound:
+		return nil, err
+	}
+
+	sort.Slice(scoped.Entries, func(i, j int) bool {
+		return scoped.Entries[i].Name < scoped.Entries[j].Name
+	})
+
+	return staged, r.repo.Storer.SetIndex(scoped)
+}
+
+func (r goGitRepository) Add(ctx context.Context, path string) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	rel, err := r.relative(path)
+	if err != nil {
+		return err
+	}
+
+	err = wt.AddWithOptions(&gogit.AddOptions{Path: rel})
+	if err != nil {
+		return fmt.Errorf("error during `git add`: %v", err)
+	}
+
+	return nil
+}
+
+// Returns the signature from the environment used by `git`
+// or if it isn't set from the configuration. Returns nil
+// if the signature isn't set.
+func (r goGitRepository) signature(role string, when time.Time) (*object.Signature, error) {
+	name, email := os.Getenv("GIT_"+role+"_NAME"), os.Getenv("GIT_"+role+"_EMAIL")
+	if len(name) != 0 && len(email) != 0 {
+		return &object.Signature{Name: name, Email: email, When: when}, nil
+	}
+
+	cfg, err := r.repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return nil, err
+	}
+
+	name, email = cfg.Author.Name, cfg.Author.Email
+	if role == "COMMITTER" {
+		name, email = cfg.Committer.Name, cfg.Committer.Email
+	}
+
+	if len(name) == 0 || len(email) == 0 {
+		name, email = cfg.User.Name, cfg.User.Email
+	}
+
+	if len(name) == 0 || len(email) == 0 {
+		return nil, nil
+	}
+
+	return &object.Signature{Name: name, Email: email, When: when}, nil
+}
+
+// Commits only the changes staged in the working directory.
+// Changes staged outside of it are left staged.
+func (r goGitRepository) commit(ctx context.Context, msg string, dates Dates, allowEmpty bool) (err error) {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	scope, err := r.scope()
+	if err != nil {
+		return err
+	}
+
+	if len(scope) != 0 {
+		staged, err := r.scopeIndex(scope)
+		if err != nil {
+			return err
+		}
+
+		// The changes in the scope are committed and the same
+		// in both indexes so the staged changes can be restored
+		defer func() {
+			restoreErr := r.repo.Storer.SetIndex(staged)
+			if err == nil {
+				err = restoreErr
+			}
+		}()
+	}
+
+	now := time.Now()
+	if dates.Author.IsZero() {
+		dates.Author = now
+	}
+
+	if dates.Committer.IsZero() {
+		dates.Committer = now
+	}
+
+	opts := &gogit.CommitOptions{AllowEmptyCommits: allowEmpty}
+	if r.signing.IsEnabled() {
+		opts.Signer = programSigner{r.signing}
+	}
+
+	opts.Author, err = r.signature("AUTHOR", dates.Author)
+	if err != nil {
+		return err
+	}
+
+	opts.Committer, err = r.signature("COMMITTER", dates.Committer)
+	if err != nil {
+		return err
+	}
+
+	// `git commit` terminates the message with a newline
+	_, err = wt.Commit(strings.TrimRight(msg, "\n")+"\n", opts)
+	if err == gogit.ErrEmptyCommit {
+		return ErrNothingToCommit
+	}
+
+	if err != nil {
+		return fmt.Errorf("error during `git commit`: %v", err)
+	}
+
+	return nil
+}
+
+func (r goGitRepository) Commit(ctx context.Context, msg string) error {
+	return r.commit(ctx, msg, Dates{}, false)
+}
+
+func (r goGitRepository) CommitWithDates(ctx context.Context, msg string, dates Dates) error {
+	return r.commit(ctx, msg, dates, false)
+}
+
+func (r goGitRepository) CommitEmpty(ctx context.Context, msg string) error {
+	return r.commit(ctx, msg, Dates{}, true)
+}
+
+func (r goGitRepository) CommitMsg(c Commitable) (string, error) {
+	return r.messages.Render(c)
+}
+
+func (r goGitRepository) Status(ctx context.Context) ([]FileStatus, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("error during `git status`: %v", err)
+	}
+
+	scope, err := r.scope()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]FileStatus, 0, len(s))
+	for path, fs := range s {
+		if fs.Staging == gogit.Unmodified && fs.Worktree == gogit.Unmodified {
+			continue
+		}
+
+		if !inScope(scope, path) {
+			continue
+		}
+
+		status = append(status, FileStatus{path, byte(fs.Staging), byte(fs.Worktree)})
+	}
+
+	sort.Sort(statusByPath(status))
+
+	return status, nil
+}
+
+func (r goGitRepository) RevParse(ctx context.Context, rev string) (string, error) {
+	if err := canceled(ctx); err != nil {
+		return "", err
+	}
+
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("unknown revision: %s", rev)
+	}
+
+	return hash.String(), nil
+}
+
+// Diffs the trees of the commits and keeps the
+// changes to files in the working directory
+func (r goGitRepository) Diff(ctx context.Context, from, to string) (string, error) {
+	if err := canceled(ctx); err != nil {
+		return "", err
+	}
+
+	scope, err := r.scope()
+	if err != nil {
+		return "", err
+	}
+
+	trees := make([]*object.Tree, 0, 2)
+	for _, rev := range []string{from, to} {
+		hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return "", fmt.Errorf("unknown revision: %s", rev)
+		}
+
+		commit, err := r.repo.CommitObject(*hash)
+		if err != nil {
+			return "", err
+		}
+
+		tree, err := commit.Tree()
+		if err != nil {
+			return "", err
+		}
+
+		trees = append(trees, tree)
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, trees[0], trees[1], object.DefaultDiffTreeOptions)
+	if err != nil {
+		return "", err
+	}
+
+	scoped := make(object.Changes, 0, len(changes))
+	for _, c := range changes {
+		if inScope(scope, c.From.Name) || inScope(scope, c.To.Name) {
+			scoped = append(scoped, c)
+		}
+	}
+
+	patch, err := scoped.PatchContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return patch.String(), nil
+}
+
+// Moves the branch to rev then resets the files in the working
+// directory. Files outside of it and files that aren't tracked are
+// left untouched.
+func (r goGitRepository) Reset(ctx context.Context, rev string, mode ResetMode) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("unknown revision: %s", rev)
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	scope, err := r.scope()
+	if err != nil {
+		return err
+	}
+
+	// The tracked files in the scope are reset. go-git would
+	// remove the files that aren't tracked during a hard reset.
+	isTracked := make(map[string]bool)
+	track := func(path string) {
+		if inScope(scope, path) {
+			isTracked[path] = true
+		}
+	}
+
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range idx.Entries {
+		track(e.Name)
+	}
+
+	err = r.walkCommit(*hash, func(path string, _ object.TreeEntry) { track(path) })
+	if err != nil {
+		return err
+	}
+
+	opts := &gogit.ResetOptions{Commit: *hash, Mode: gogit.MixedReset}
+	if mode == HardReset {
+		opts.Mode = gogit.HardReset
+	}
+
+	for path := range isTracked {
+		opts.Files = append(opts.Files, path)
+	}
+
+	if len(opts.Files) == 0 {
+		opts.Mode = gogit.SoftReset
+	}
+
+	err = wt.Reset(opts)
+	if err != nil {
+		return fmt.Errorf("error during `git reset`: %v", err)
+	}
+
+	return nil
+}
+
+func (r goGitRepository) Verify(ctx context.Context, rev string, path string) ([]Verification, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision: %s", rev)
+	}
+
+	opts := &gogit.LogOptions{From: *hash}
+	if len(path) != 0 {
+		rel, err := r.relative(path)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.PathFilter = func(p string) bool {
+			return rel == "." || p == rel || strings.HasPrefix(p, rel+"/")
+		}
+	}
+
+	commits, err := r.repo.Log(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+
+	verifications := make([]Verification, 0, 8)
+	err = commits.ForEach(func(c *object.Commit) error {
+		if err := canceled(ctx); err != nil {
+			return err
+		}
+
+		v := Verification{
+			Hash:    c.Hash.String(),
+			Subject: strings.SplitN(c.Message, "\n", 2)[0],
+			Status:  NoSignature,
+		}
+
+		if len(c.PGPSignature) != 0 {
+			encoded := &plumbing.MemoryObject{}
+			err := c.EncodeWithoutSignature(encoded)
+			if err != nil {
+				return err
+			}
+
+			payload, err := encoded.Reader()
+			if err != nil {
+				return err
+			}
+			defer payload.Close()
+
+			data, err := ioutil.ReadAll(payload)
+			if err != nil {
+				return err
+			}
+
+			v.Status, err = r.signing.verify(data, c.PGPSignature)
+			if err != nil {
+				return err
+			}
+		}
+
+		verifications = append(verifications, v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return verifications, nil
+}
+
+func (r goGitRepository) Log(ctx context.Context, rev string, max int) ([]LogEntry, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision: %s", rev)
+	}
+
+	commits, err := r.repo.Log(&gogit.LogOptions{From: *hash})
+	if err != nil {
+		return nil, err
+	}
+	defer commits.Close()
+
+	entries := make([]LogEntry, 0, 8)
+	for max == 0 || len(entries) < max {
+		if err := canceled(ctx); err != nil {
+			return nil, err
+		}
+
+		c, err := commits.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		parents := make([]string, 0, len(c.ParentHashes))
+		for _, parent := range c.ParentHashes {
+			parents = append(parents, parent.String())
+		}
+
+		entries = append(entries, LogEntry{
+			Hash:           c.Hash.String(),
+			Parents:        parents,
+			AuthorName:     c.Author.Name,
+			AuthorEmail:    c.Author.Email,
+			AuthorTime:     c.Author.When,
+			CommitterName:  c.Committer.Name,
+			CommitterEmail: c.Committer.Email,
+			CommitterTime:  c.Committer.When,
+			Message:        strings.TrimRight(c.Message, "\n"),
+		})
+	}
+
+	return entries, nil
+}
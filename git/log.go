@@ -0,0 +1,221 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Selects the commits that are read by Log
+type LogOptions struct {
+	// The revision or range of revisions, e.g. "HEAD~5..HEAD".
+	// If empty the commits reachable from HEAD are read.
+	Range string
+
+	// If not empty only the commits that changed one of
+	// the paths are read. The paths are relative to the
+	// working directory or absolute.
+	Paths []string
+
+	// The maximum number of commits read, all of them if 0
+	Max int
+
+	// Read the oldest commit first
+	Reverse bool
+}
+
+// The fields of a commit are separated by \x1f and each commit
+// begins with \x1e. The message is the last field so it's the only
+// field that can contain a newline. It's followed by the paths.
+const logFormat = "--format=%x1e%H%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%cn%x1f%ce%x1f%cI%x1f%B%x1f"
+
+const logFields = 9
+
+// Reads the commits of a `git log` while it's executing. Each call to
+// Scan reads the next commit so the history is never loaded into memory.
+type LogScanner struct {
+	ctx    context.Context
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+	stderr bytes.Buffer
+	r      *bufio.Reader
+
+	entry     *LogEntry
+	lastError error
+	done      bool
+}
+
+// Execute `git log` in workingDirectory and return a scanner for
+// the commits it outputs. The scanner must be closed if Scan isn't
+// called until it returns false. `git log` is interrupted when ctx is done.
+func Log(ctx context.Context, workingDirectory string, opts LogOptions) (*LogScanner, error) {
+	args := []string{"log", "-z", "--name-only", logFormat}
+	if opts.Max > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", opts.Max))
+	}
+
+	if opts.Reverse {
+		args = append(args, "--reverse")
+	}
+
+	if len(opts.Range) != 0 {
+		args = append(args, opts.Range)
+	}
+
+	args = append(args, "--")
+	args = append(args, opts.Paths...)
+
+	s := &LogScanner{ctx: ctx, cmd: CommandContext(ctx, workingDirectory, args...)}
+	s.cmd.Stderr = &s.stderr
+
+	stdout, err := s.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	s.stdout = stdout
+	s.r = bufio.NewReader(stdout)
+
+	// Discard everything before the first commit
+	_, err = s.r.ReadBytes('\x1e')
+	if err == io.EOF {
+		s.lastError = s.wait()
+	} else if err != nil {
+		s.lastError = err
+	}
+
+	return s, nil
+}
+
+// Read the next commit. Returns false when all the
+// commits have been read or an error occurred.
+func (s *LogScanner) Scan() bool {
+	if s.lastError != nil || s.done {
+		return false
+	}
+
+	record, err := s.r.ReadBytes('\x1e')
+	switch {
+	case err == io.EOF:
+		if len(record) == 0 {
+			s.lastError = s.wait()
+			return false
+		}
+
+	case err != nil:
+		s.lastError = err
+		s.Close()
+		return false
+
+	default:
+		record = record[:len(record)-1]
+	}
+
+	s.entry, err = parseLogEntry(record)
+	if err != nil {
+		s.lastError = err
+		s.Close()
+		return false
+	}
+
+	return true
+}
+
+// Returns the commit read by the last call to Scan
+func (s *LogScanner) Entry() *LogEntry {
+	return s.entry
+}
+
+// Returns the error that caused Scan to return false.
+// Returns nil if all the commits were read.
+func (s *LogScanner) Err() error {
+	return s.lastError
+}
+
+// Stop reading commits and wait for `git log` to exit
+func (s *LogScanner) Close() error {
+	if s.done {
+		return nil
+	}
+
+	s.stdout.Close()
+	s.done = true
+
+	// git exits when writing to the closed pipe fails
+	s.cmd.Wait()
+	return nil
+}
+
+func (s *LogScanner) wait() error {
+	s.done = true
+
+	err := s.cmd.Wait()
+	if err != nil && s.ctx.Err() != nil {
+		return context.Cause(s.ctx)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return Error{
+			Args:     s.cmd.Args[1:],
+			ExitCode: exitErr.ExitCode(),
+			Stderr:   s.stderr.Bytes(),
+		}
+	}
+
+	return err
+}
+
+func parseLogEntry(record []byte) (*LogEntry, error) {
+	fields := strings.SplitN(string(record), "\x1f", logFields)
+	if len(fields) != logFields {
+		return nil, fmt.Errorf("error parsing `git log`: %q", record)
+	}
+
+	// The message is followed by the paths
+	i := strings.LastIndex(fields[8], "\x1f")
+	if i == -1 {
+		return nil, fmt.Errorf("error parsing `git log`: %q", record)
+	}
+	message, paths := fields[8][:i], fields[8][i+1:]
+
+	authoredAt, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return nil, err
+	}
+
+	committedAt, err := time.Parse(time.RFC3339, fields[7])
+	if err != nil {
+		return nil, err
+	}
+
+	e := &LogEntry{
+		Hash:           fields[0],
+		Parents:        strings.Fields(fields[1]),
+		AuthorName:     fields[2],
+		AuthorEmail:    fields[3],
+		AuthorTime:     authoredAt,
+		CommitterName:  fields[5],
+		CommitterEmail: fields[6],
+		CommitterTime:  committedAt,
+		Message:        strings.TrimRight(message, "\n"),
+	}
+
+	for _, path := range strings.Split(paths, "\x00") {
+		path = strings.TrimLeft(path, "\n")
+		if len(path) != 0 {
+			e.Paths = append(e.Paths, path)
+		}
+	}
+
+	return e, nil
+}
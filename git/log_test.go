@@ -0,0 +1,116 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeLog(c gospec.Context) {
+	ctx := context.Background()
+
+	c.Specify("the log of a repository", func() {
+		d, err := ioutil.TempDir("", "git_log_test")
+		c.Assume(err, IsNil)
+
+		defer func(dir string) {
+			c.Expect(os.RemoveAll(dir), IsNil)
+		}(d)
+
+		c.Assume(Init(ctx, d), IsNil)
+
+		commitFile := func(path, data, msg string, authoredAt time.Time) {
+			c.Assume(os.MkdirAll(filepath.Join(d, filepath.Dir(path)), 0700), IsNil)
+			c.Assume(ioutil.WriteFile(filepath.Join(d, path), []byte(data), 0600), IsNil)
+			c.Assume(AddFilepath(ctx, d, path), IsNil)
+			c.Assume(commitWithDates(ctx, d, []string{"commit", "-m", msg}, Dates{Author: authoredAt}), IsNil)
+		}
+
+		est := time.FixedZone("EST", -5*60*60)
+
+		commitFile("idea/1", "an idea\n", "idea - created - 1", time.Date(2015, 1, 1, 10, 0, 0, 0, est))
+		commitFile("entry/2015-01-01-1000-EST", "an entry\n", "An Entry\n\nwith a body", time.Date(2015, 1, 1, 11, 0, 0, 0, est))
+		commitFile("idea/1", "an updated idea\n", "idea - updated - 1", time.Date(2015, 1, 2, 10, 0, 0, 0, est))
+
+		scanAll := func(opts LogOptions) (entries []LogEntry) {
+			commits, err := Log(ctx, d, opts)
+			c.Assume(err, IsNil)
+
+			for commits.Scan() {
+				entries = append(entries, *commits.Entry())
+			}
+			c.Assume(commits.Err(), IsNil)
+
+			return
+		}
+
+		subjects := func(entries []LogEntry) string {
+			s := make([]string, 0, len(entries))
+			for _, e := range entries {
+				s = append(s, e.Subject())
+			}
+			return fmt.Sprint(s)
+		}
+
+		c.Specify("can be read newest first", func() {
+			entries := scanAll(LogOptions{})
+			c.Assume(len(entries), Equals, 3)
+			c.Expect(subjects(entries), Equals, "[idea - updated - 1 An Entry idea - created - 1]")
+
+			entry := entries[1]
+			c.Expect(entry.Message, Equals, "An Entry\n\nwith a body")
+			c.Expect(fmt.Sprint(entry.Paths), Equals, "[entry/2015-01-01-1000-EST]")
+			c.Expect(fmt.Sprint(entry.Parents), Equals, fmt.Sprint([]string{entries[2].Hash}))
+			c.Expect(entry.AuthorTime.Equal(time.Date(2015, 1, 1, 11, 0, 0, 0, est)), IsTrue)
+
+			hash, err := Command(d, "rev-parse", "HEAD~1").Output()
+			c.Assume(err, IsNil)
+			c.Expect(entry.Hash+"\n", Equals, string(hash))
+
+			c.Expect(len(entries[2].Parents), Equals, 0)
+		})
+
+		c.Specify("can be read oldest first", func() {
+			c.Expect(subjects(scanAll(LogOptions{Reverse: true})), Equals, "[idea - created - 1 An Entry idea - updated - 1]")
+		})
+
+		c.Specify("can be limited", func() {
+			c.Specify("to a number of commits", func() {
+				c.Expect(subjects(scanAll(LogOptions{Max: 2})), Equals, "[idea - updated - 1 An Entry]")
+			})
+
+			c.Specify("to a range", func() {
+				c.Expect(subjects(scanAll(LogOptions{Range: "HEAD~2..HEAD~1"})), Equals, "[An Entry]")
+			})
+
+			c.Specify("to the commits that changed a path", func() {
+				c.Expect(subjects(scanAll(LogOptions{Paths: []string{"idea/1"}})), Equals, "[idea - updated - 1 idea - created - 1]")
+				c.Expect(subjects(scanAll(LogOptions{Paths: []string{filepath.Join(d, "entry")}})), Equals, "[An Entry]")
+			})
+		})
+
+		c.Specify("can be closed before it's read", func() {
+			commits, err := Log(ctx, d, LogOptions{})
+			c.Assume(err, IsNil)
+
+			c.Assume(commits.Scan(), IsTrue)
+			c.Expect(commits.Close(), IsNil)
+			c.Expect(commits.Scan(), IsFalse)
+			c.Expect(commits.Err(), IsNil)
+		})
+
+		c.Specify("will fail to read an unknown revision", func() {
+			commits, err := Log(ctx, d, LogOptions{Range: "unknown"})
+			c.Assume(err, IsNil)
+
+			c.Expect(commits.Scan(), IsFalse)
+			c.Expect(commits.Err(), Not(IsNil))
+		})
+	})
+}
@@ -0,0 +1,143 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The kinds of commits a journal makes. The message of each kind
+// of commit can be rendered by a template.
+const (
+	EntryCommit       = "entry"
+	IdeaCreatedCommit = "ideaCreated"
+	IdeaUpdatedCommit = "ideaUpdated"
+	InitCommit        = "init"
+	FixCommit         = "fix"
+
+	// The template for every kind of commit that doesn't have its own
+	DefaultCommit = "default"
+)
+
+var msgKinds = []string{EntryCommit, IdeaCreatedCommit, IdeaUpdatedCommit, InitCommit, FixCommit, DefaultCommit}
+
+// An idea as it's described to a commit message template
+type IdeaDescription struct {
+	Id     uint
+	Name   string
+	Status string
+}
+
+// Describes a commit to the template that renders its message
+type Description struct {
+	// The kind of commit, one of the *Commit constants or empty
+	Kind string
+
+	// The title of the entry being committed
+	Title string
+
+	// When the entry being committed was opened and closed
+	OpenedAt, ClosedAt time.Time
+
+	// The idea being committed, the zero value if the
+	// commit doesn't change a single idea
+	Idea IdeaDescription
+
+	// Added to the end of the message after it's rendered
+	Trailers []Trailer
+}
+
+// The value a commit message template is executed with
+type MsgData struct {
+	Description
+
+	// The message the commit is made with if there isn't a template
+	Msg string
+
+	// When the message is rendered
+	Now time.Time
+}
+
+// Returned when a template is configured for a kind of commit that doesn't exist
+type UnknownCommitKindError struct {
+	Kind string
+}
+
+func (e UnknownCommitKindError) Error() string {
+	return fmt.Sprintf("unknown kind of commit: %s, expected one of %s", e.Kind, strings.Join(msgKinds, ", "))
+}
+
+func IsUnknownCommitKindError(err error) bool {
+	_, ok := err.(UnknownCommitKindError)
+	return ok
+}
+
+// The templates that render commit messages by the kind of commit
+type MsgTemplates map[string]*template.Template
+
+// Parses the templates for each kind of commit. The templates
+// are Go text/templates that are executed with a MsgData.
+func ParseMsgTemplates(templates map[string]string) (MsgTemplates, error) {
+	parsed := make(MsgTemplates, len(templates))
+
+	for kind, text := range templates {
+		if !isMsgKind(kind) {
+			return nil, UnknownCommitKindError{kind}
+		}
+
+		t, err := template.New(kind).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the %s commit message template: %v", kind, err)
+		}
+
+		parsed[kind] = t
+	}
+
+	return parsed, nil
+}
+
+func isMsgKind(kind string) bool {
+	for _, k := range msgKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the message the commitable is committed with. It's rendered by
+// the template for its kind of commit or the default template. If neither
+// template exists the commitable's message is used. The trailers of the
+// commitable are added to the end of the message.
+func (t MsgTemplates) Render(c Commitable) (string, error) {
+	d := c.CommitDescription()
+
+	kind := d.Kind
+	tmpl, exists := t[kind]
+	if !exists {
+		kind = DefaultCommit
+		tmpl, exists = t[kind]
+	}
+
+	if !exists {
+		return appendTrailers(c.CommitMsg(), d.Trailers), nil
+	}
+
+	msg := bytes.NewBuffer(nil)
+	err := tmpl.Execute(msg, MsgData{
+		Description: d,
+		Msg:         c.CommitMsg(),
+		Now:         time.Now(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering the %s commit message: %v", kind, err)
+	}
+
+	if len(strings.TrimSpace(msg.String())) == 0 {
+		return "", fmt.Errorf("the %s commit message template rendered an empty message", kind)
+	}
+
+	return appendTrailers(msg.String(), d.Trailers), nil
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"time"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeMsgTemplates(c gospec.Context) {
+	openedAt := time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC)
+
+	entry := &Changes{
+		Msg: "a title",
+		Description: Description{
+			Kind:     EntryCommit,
+			Title:    "a title",
+			OpenedAt: openedAt,
+			ClosedAt: openedAt.Add(time.Hour),
+		},
+	}
+
+	idea := &Changes{
+		Msg: "idea - updated - 3",
+		Description: Description{
+			Kind: IdeaUpdatedCommit,
+			Idea: IdeaDescription{Id: 3, Name: "an idea", Status: "active"},
+		},
+	}
+
+	c.Specify("commit message templates", func() {
+		c.Specify("will render a commit with the template for its kind", func() {
+			t, err := ParseMsgTemplates(map[string]string{
+				EntryCommit:       `entry: {{.Title}} ({{.OpenedAt.Format "2006-01-02"}})`,
+				IdeaUpdatedCommit: `idea {{.Idea.Id}} {{.Idea.Name}} is {{.Idea.Status}}`,
+			})
+			c.Assume(err, IsNil)
+
+			msg, err := t.Render(entry)
+			c.Expect(err, IsNil)
+			c.Expect(msg, Equals, "entry: a title (2024-03-01)")
+
+			msg, err = t.Render(idea)
+			c.Expect(err, IsNil)
+			c.Expect(msg, Equals, "idea 3 an idea is active")
+		})
+
+		c.Specify("will render a commit with the default template", func() {
+			t, err := ParseMsgTemplates(map[string]string{
+				DefaultCommit: `journal: {{.Msg}}`,
+			})
+			c.Assume(err, IsNil)
+
+			msg, err := t.Render(idea)
+			c.Expect(err, IsNil)
+			c.Expect(msg, Equals, "journal: idea - updated - 3")
+		})
+
+		c.Specify("will use the message of a commit without a template", func() {
+			var t MsgTemplates
+
+			msg, err := t.Render(entry)
+			c.Expect(err, IsNil)
+			c.Expect(msg, Equals, "a title")
+		})
+
+		c.Specify("will fail to parse", func() {
+			c.Specify("a template for a kind of commit that doesn't exist", func() {
+				_, err := ParseMsgTemplates(map[string]string{"merge": `{{.Msg}}`})
+				c.Expect(IsUnknownCommitKindError(err), IsTrue)
+			})
+
+			c.Specify("a template that isn't valid", func() {
+				_, err := ParseMsgTemplates(map[string]string{EntryCommit: `{{.Title`})
+				c.Expect(err, Not(IsNil))
+			})
+		})
+
+		c.Specify("will fail to render", func() {
+			c.Specify("a field that doesn't exist", func() {
+				t, err := ParseMsgTemplates(map[string]string{EntryCommit: `{{.Author}}`})
+				c.Assume(err, IsNil)
+
+				_, err = t.Render(entry)
+				c.Expect(err, Not(IsNil))
+			})
+
+			c.Specify("an empty message", func() {
+				t, err := ParseMsgTemplates(map[string]string{EntryCommit: `{{if false}}x{{end}}`})
+				c.Assume(err, IsNil)
+
+				_, err = t.Render(entry)
+				c.Expect(err, Not(IsNil))
+			})
+		})
+	})
+}
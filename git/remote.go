@@ -0,0 +1,92 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Returns the names of the remotes configured in the repository
+func Remotes(ctx context.Context, workingDirectory string) ([]string, error) {
+	o, err := Exec(ctx, workingDirectory, "remote")
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(o)), nil
+}
+
+// Returns the name of the branch that is checked out
+func CurrentBranch(ctx context.Context, workingDirectory string) (string, error) {
+	o, err := Exec(ctx, workingDirectory, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("a branch must be checked out")
+	}
+
+	return string(bytes.TrimSpace(o)), nil
+}
+
+// Returns true if rev names a commit
+func HasRev(ctx context.Context, workingDirectory string, rev string) bool {
+	_, err := Exec(ctx, workingDirectory, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	return err == nil
+}
+
+// Execute `git fetch {remote}` in workingDirectory
+func Fetch(ctx context.Context, workingDirectory string, remote string) error {
+	_, err := Exec(ctx, workingDirectory, "fetch", "-q", remote)
+	return err
+}
+
+// Execute `git merge {rev}` in workingDirectory. If the merge stops on
+// conflicts it's left in progress and UnmergedFiles will list the conflicts.
+func Merge(ctx context.Context, workingDirectory string, rev string) error {
+	_, err := Exec(ctx, workingDirectory, "merge", "-q", "--no-edit", rev)
+	return err
+}
+
+// Execute `git rebase {rev}` in workingDirectory. If the rebase stops on
+// conflicts it's left in progress and UnmergedFiles will list the conflicts.
+func Rebase(ctx context.Context, workingDirectory string, rev string) error {
+	_, err := Exec(ctx, workingDirectory, "rebase", "-q", rev)
+	return err
+}
+
+// Execute `git rebase --abort` in workingDirectory
+func AbortRebase(ctx context.Context, workingDirectory string) error {
+	_, err := Exec(ctx, workingDirectory, "rebase", "--abort")
+	return err
+}
+
+// Execute `git push {remote} {branch}` in workingDirectory
+func Push(ctx context.Context, workingDirectory string, remote, branch string) error {
+	_, err := Exec(ctx, workingDirectory, "push", "-q", remote, branch)
+	return err
+}
+
+// Returns the absolute paths of the files that have merge conflicts
+func UnmergedFiles(ctx context.Context, workingDirectory string) ([]string, error) {
+	root, err := Exec(ctx, workingDirectory, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := Exec(ctx, workingDirectory, "diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, 4)
+	for _, path := range strings.Split(string(o), "\x00") {
+		if len(path) != 0 {
+			paths = append(paths, filepath.Join(string(bytes.TrimSpace(root)), filepath.FromSlash(path)))
+		}
+	}
+
+	return paths, nil
+}
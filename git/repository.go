@@ -0,0 +1,305 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The names of the backends a Repository can be opened with
+const (
+	// Executes the `git` binary
+	ExecBackend = "exec"
+
+	// Implemented in go using go-git, doesn't require git to be installed
+	GoGitBackend = "go-git"
+
+	// Uses the exec backend if git is installed
+	// and the go-git backend if it isn't
+	AutoBackend = ""
+)
+
+// A git repository containing a journal. The journal may be in a
+// directory within the repository. A Repository only stages, commits
+// and resets the files in its working directory and reports their
+// status. Changes to the rest of the repository are left untouched.
+// Every operation is canceled when its ctx is done.
+type Repository interface {
+	// The directory the repository was opened in
+	WorkingDirectory() string
+
+	// Stage the file or directory at path. The path is
+	// relative to the working directory or absolute.
+	Add(ctx context.Context, path string) error
+
+	// Commit the changes staged in the working directory
+	Commit(ctx context.Context, msg string) error
+
+	// Commit the staged changes with the author and committer dates
+	CommitWithDates(ctx context.Context, msg string, dates Dates) error
+
+	// Commit w/o any changes
+	CommitEmpty(ctx context.Context, msg string) error
+
+	// Returns the message the commitable is committed with,
+	// rendered by the repository's commit message templates
+	CommitMsg(c Commitable) (string, error)
+
+	// Returns the files in the working directory that have been modified,
+	// staged or aren't tracked. A working directory without any changes
+	// will return an empty slice.
+	Status(ctx context.Context) ([]FileStatus, error)
+
+	// Returns the hash of the commit named by rev
+	RevParse(ctx context.Context, rev string) (string, error)
+
+	// Returns at most max commits reachable from rev, newest first.
+	// If max is 0 all the commits are returned.
+	Log(ctx context.Context, rev string, max int) ([]LogEntry, error)
+
+	// Returns a unified diff of the files in the working directory that
+	// changed between the commits from and to. Renamed files are detected
+	// and paths are relative to the root of the repository.
+	Diff(ctx context.Context, from, to string) (string, error)
+
+	// Reset the current branch to rev and the files in the working directory
+	Reset(ctx context.Context, rev string, mode ResetMode) error
+
+	// Verifies the signatures of the commits reachable from rev, newest first.
+	// If path isn't empty only the commits that changed path are verified.
+	Verify(ctx context.Context, rev string, path string) ([]Verification, error)
+}
+
+// Configures how a repository is opened
+type Options struct {
+	// The name of the backend
+	Backend string
+
+	// How commits are signed and verified
+	Signing Signing
+
+	// Renders the message of every commitable committed to the repository
+	Messages MsgTemplates
+}
+
+// How Repository.Reset treats the index and the working tree
+type ResetMode int
+
+const (
+	// Resets the index but not the working tree, `git reset --mixed`
+	MixedReset ResetMode = iota
+
+	// Resets the index and the working tree, `git reset --hard`.
+	// Files that aren't tracked are not removed.
+	HardReset
+)
+
+// The status of a file in the repository
+type FileStatus struct {
+	// The path of the file relative to the root of the repository
+	Path string
+
+	// The status of the file in the index and in the working tree.
+	// The codes are the same as the short format of `git status`.
+	Staging, Worktree byte
+}
+
+func (s FileStatus) String() string {
+	return fmt.Sprintf("%c%c %s", s.Staging, s.Worktree, s.Path)
+}
+
+// A commit returned by Repository.Log or read by a LogScanner
+type LogEntry struct {
+	Hash    string
+	Parents []string
+
+	AuthorName  string
+	AuthorEmail string
+	AuthorTime  time.Time
+
+	CommitterName  string
+	CommitterEmail string
+	CommitterTime  time.Time
+
+	// The commit message w/o trailing newlines
+	Message string
+
+	// The paths changed by the commit relative to the root of the
+	// repository. Only read by a LogScanner, the paths of a merge are empty.
+	Paths []string
+}
+
+// Returns the first line of the message
+func (e LogEntry) Subject() string {
+	return strings.SplitN(e.Message, "\n", 2)[0]
+}
+
+// Returned when opening a repository with a backend that doesn't exist
+type UnknownBackendError struct {
+	Backend string
+}
+
+func (e UnknownBackendError) Error() string {
+	return fmt.Sprintf("unknown git backend: %s", e.Backend)
+}
+
+func IsUnknownBackendError(err error) bool {
+	_, ok := err.(UnknownBackendError)
+	return ok
+}
+
+func backendFor(backend string) (string, error) {
+	switch backend {
+	case ExecBackend, GoGitBackend:
+		return backend, nil
+
+	case AutoBackend:
+		if _, err := lookPath(); err != nil {
+			return GoGitBackend, nil
+		}
+		return ExecBackend, nil
+	}
+
+	return "", UnknownBackendError{backend}
+}
+
+// Open the repository containing directory using the backend
+func Open(directory, backend string) (Repository, error) {
+	return OpenWith(directory, Options{Backend: backend})
+}
+
+// Open the repository containing directory with the options
+func OpenWith(directory string, opts Options) (Repository, error) {
+	backend, err := backendFor(opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	err = opts.Signing.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	switch backend {
+	case GoGitBackend:
+		r, err := OpenGoGit(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		repo := r.(goGitRepository)
+		repo.signing = opts.Signing
+		repo.messages = opts.Messages
+		return repo, nil
+
+	default:
+		r, err := OpenExec(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		repo := r.(execRepository)
+		repo.signing = opts.Signing
+		repo.messages = opts.Messages
+		return repo, nil
+	}
+}
+
+// Create a repository in directory using the backend
+func InitRepository(ctx context.Context, directory, backend string) (Repository, error) {
+	backend, err := backendFor(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	switch backend {
+	case GoGitBackend:
+		return InitGoGit(directory)
+	default:
+		err := Init(ctx, directory)
+		if err != nil {
+			return nil, err
+		}
+		return OpenExec(directory)
+	}
+}
+
+// Stage all Changes() of the commitable in the repository
+// then commit them with the rendered CommitMsg() and CommitDates()
+func CommitTo(ctx context.Context, r Repository, c Commitable) error {
+	msg, err := r.CommitMsg(c)
+	if err != nil {
+		return err
+	}
+
+	d := c.WorkingDirectory()
+
+	for _, change := range c.Changes() {
+		path := change.Filepath()
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(d, path)
+		}
+
+		err := r.Add(ctx, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.CommitWithDates(ctx, msg, c.CommitDates())
+}
+
+// Commit w/o any changes with the rendered CommitMsg() of the commitable
+func CommitEmptyTo(ctx context.Context, r Repository, c Commitable) error {
+	msg, err := r.CommitMsg(c)
+	if err != nil {
+		return err
+	}
+
+	return r.CommitEmpty(ctx, msg)
+}
+
+// Returns the git directory of the repository containing directory
+// without executing git. Returns an error if directory isn't within a repository.
+func FindGitDir(directory string) (string, error) {
+	dir, err := filepath.Abs(directory)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+
+		fi, err := os.Stat(dotGit)
+		switch {
+		case err == nil && fi.IsDir():
+			return dotGit, nil
+
+		case err == nil:
+			// A worktree or submodule links to its git directory
+			data, err := ioutil.ReadFile(dotGit)
+			if err != nil {
+				return "", err
+			}
+
+			gitDir := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			return gitDir, nil
+
+		case !os.IsNotExist(err):
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", NotARepoError{directory}
+		}
+		dir = parent
+	}
+}
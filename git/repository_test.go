@@ -0,0 +1,377 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+	. "github.com/ghthor/journal/git/gittest"
+)
+
+func DescribeRepository(c gospec.Context) {
+	ctx := context.Background()
+
+	for _, backend := range []string{ExecBackend, GoGitBackend} {
+		backend := backend
+
+		c.Specify(fmt.Sprintf("a repository using the %s backend", backend), func() {
+			d, err := ioutil.TempDir("", "git_repository_test")
+			c.Assume(err, IsNil)
+
+			defer func(dir string) {
+				c.Expect(os.RemoveAll(dir), IsNil)
+			}(d)
+
+			d = filepath.Join(d, "a_git_repo")
+			r, err := InitRepository(ctx, d, backend)
+			c.Assume(err, IsNil)
+			c.Expect(d, IsAGitRepository)
+			c.Expect(r.WorkingDirectory(), Equals, d)
+
+			c.Assume(os.Mkdir(filepath.Join(d, "idea"), 0700), IsNil)
+			c.Assume(ioutil.WriteFile(filepath.Join(d, "idea", "1"), []byte("an idea\n"), 0600), IsNil)
+
+			c.Specify("will list the files that aren't tracked", func() {
+				status, err := r.Status(ctx)
+				c.Assume(err, IsNil)
+				c.Expect(fmt.Sprint(status), Equals, "[?? idea/1]")
+			})
+
+			c.Specify("will stage a file", func() {
+				c.Assume(r.Add(ctx, filepath.Join(d, "idea", "1")), IsNil)
+
+				status, err := r.Status(ctx)
+				c.Assume(err, IsNil)
+				c.Expect(fmt.Sprint(status), Equals, "[A  idea/1]")
+			})
+
+			c.Specify("will commit the staged changes", func() {
+				c.Assume(r.Add(ctx, "idea/1"), IsNil)
+				c.Assume(r.Commit(ctx, "a commit msg"), IsNil)
+				c.Assume(r.CommitEmpty(ctx, "an empty commit"), IsNil)
+
+				status, err := r.Status(ctx)
+				c.Assume(err, IsNil)
+				c.Expect(len(status), Equals, 0)
+
+				o, err := Command(d, "log", "--format=%s").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(o), Equals, "an empty commit\na commit msg\n")
+
+				c.Specify("and will fail to commit without any changes", func() {
+					c.Expect(r.Commit(ctx, "nothing"), Not(IsNil))
+				})
+
+				c.Specify("and will resolve a revision", func() {
+					o, err := Command(d, "rev-parse", "HEAD~1").Output()
+					c.Assume(err, IsNil)
+
+					hash, err := r.RevParse(ctx, "HEAD~1")
+					c.Assume(err, IsNil)
+					c.Expect(hash+"\n", Equals, string(o))
+
+					_, err = r.RevParse(ctx, "unknown")
+					c.Expect(err, Not(IsNil))
+				})
+
+				c.Specify("and will list the commits", func() {
+					entries, err := r.Log(ctx, "HEAD", 0)
+					c.Assume(err, IsNil)
+					c.Assume(len(entries), Equals, 2)
+					c.Expect(entries[0].Message, Equals, "an empty commit")
+					c.Expect(entries[1].Message, Equals, "a commit msg")
+
+					hash, err := r.RevParse(ctx, "HEAD")
+					c.Assume(err, IsNil)
+					c.Expect(entries[0].Hash, Equals, hash)
+
+					entries, err = r.Log(ctx, "HEAD", 1)
+					c.Assume(err, IsNil)
+					c.Expect(len(entries), Equals, 1)
+				})
+
+				c.Specify("and will commit with the author and committer dates", func() {
+					authoredAt := time.Date(2015, 1, 1, 10, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+					committedAt := authoredAt.Add(time.Hour)
+
+					c.Assume(ioutil.WriteFile(filepath.Join(d, "idea", "1"), []byte("a modified idea\n"), 0600), IsNil)
+					c.Assume(r.Add(ctx, "idea/1"), IsNil)
+					c.Assume(r.CommitWithDates(ctx, "a dated commit", Dates{authoredAt, committedAt}), IsNil)
+
+					o, err := Command(d, "log", "-1", "--format=%ai|%ci").Output()
+					c.Assume(err, IsNil)
+					c.Expect(string(o), Equals, "2015-01-01 10:00:00 -0500|2015-01-01 11:00:00 -0500\n")
+
+					entries, err := r.Log(ctx, "HEAD", 1)
+					c.Assume(err, IsNil)
+					c.Assume(len(entries), Equals, 1)
+					c.Expect(entries[0].AuthorTime.Equal(authoredAt), IsTrue)
+				})
+
+				c.Specify("and will diff two commits", func() {
+					c.Assume(ioutil.WriteFile(filepath.Join(d, "idea", "1"), []byte("a modified idea\n"), 0600), IsNil)
+					c.Assume(r.Add(ctx, "idea/1"), IsNil)
+					c.Assume(r.Commit(ctx, "modify the idea"), IsNil)
+
+					diff, err := r.Diff(ctx, "HEAD~1", "HEAD")
+					c.Assume(err, IsNil)
+					c.Expect(strings.Contains(diff, "--- a/idea/1\n+++ b/idea/1\n"), IsTrue)
+					c.Expect(strings.Contains(diff, "\n-an idea\n+a modified idea\n"), IsTrue)
+
+					diff, err = r.Diff(ctx, "HEAD", "HEAD")
+					c.Assume(err, IsNil)
+					c.Expect(diff, Equals, "")
+				})
+
+				c.Specify("and will reset the branch", func() {
+					start, err := r.RevParse(ctx, "HEAD")
+					c.Assume(err, IsNil)
+
+					c.Assume(ioutil.WriteFile(filepath.Join(d, "idea", "1"), []byte("a modified idea\n"), 0600), IsNil)
+					c.Assume(ioutil.WriteFile(filepath.Join(d, "idea", "2"), []byte("another idea\n"), 0600), IsNil)
+					c.Assume(r.Add(ctx, "idea"), IsNil)
+					c.Assume(r.Commit(ctx, "a commit to undo"), IsNil)
+
+					c.Specify("and keep the changes in the working tree", func() {
+						c.Assume(r.Reset(ctx, start, MixedReset), IsNil)
+
+						hash, err := r.RevParse(ctx, "HEAD")
+						c.Assume(err, IsNil)
+						c.Expect(hash, Equals, start)
+
+						status, err := r.Status(ctx)
+						c.Assume(err, IsNil)
+						c.Expect(fmt.Sprint(status), Equals, "[ M idea/1 ?? idea/2]")
+					})
+
+					c.Specify("and discard the changes in the working tree", func() {
+						c.Assume(ioutil.WriteFile(filepath.Join(d, "untracked"), []byte("untracked\n"), 0600), IsNil)
+						c.Assume(r.Reset(ctx, start, HardReset), IsNil)
+
+						hash, err := r.RevParse(ctx, "HEAD")
+						c.Assume(err, IsNil)
+						c.Expect(hash, Equals, start)
+
+						data, err := ioutil.ReadFile(filepath.Join(d, "idea", "1"))
+						c.Assume(err, IsNil)
+						c.Expect(string(data), Equals, "an idea\n")
+
+						status, err := r.Status(ctx)
+						c.Assume(err, IsNil)
+						c.Expect(fmt.Sprint(status), Equals, "[?? untracked]")
+					})
+				})
+
+				c.Specify("and will stage a removed file", func() {
+					c.Assume(os.Remove(filepath.Join(d, "idea", "1")), IsNil)
+					c.Assume(r.Add(ctx, "idea/1"), IsNil)
+
+					status, err := r.Status(ctx)
+					c.Assume(err, IsNil)
+					c.Expect(fmt.Sprint(status), Equals, "[D  idea/1]")
+				})
+			})
+
+			c.Specify("won't change anything if its context is canceled", func() {
+				interrupted := errors.New("interrupted")
+				canceled, cancel := context.WithCancelCause(ctx)
+				cancel(interrupted)
+
+				c.Expect(r.Add(canceled, "idea/1"), Equals, interrupted)
+				c.Expect(r.CommitEmpty(canceled, "an empty commit"), Equals, interrupted)
+
+				status, err := r.Status(ctx)
+				c.Assume(err, IsNil)
+				c.Expect(fmt.Sprint(status), Equals, "[?? idea/1]")
+
+				_, err = os.Stat(filepath.Join(d, ".git", "index.lock"))
+				c.Expect(os.IsNotExist(err), IsTrue)
+			})
+
+			c.Specify("will be opened from a directory within it", func() {
+				r, err := Open(filepath.Join(d, "idea"), backend)
+				c.Assume(err, IsNil)
+
+				changes := NewChangesIn(filepath.Join(d, "idea"))
+				changes.Add(ChangedFile("1"))
+				changes.Msg = "a commit msg"
+				c.Assume(CommitTo(ctx, r, changes), IsNil)
+
+				status, err := r.Status(ctx)
+				c.Assume(err, IsNil)
+				c.Expect(len(status), Equals, 0)
+			})
+
+			c.Specify("will only change the files in a journal within it", func() {
+				c.Assume(ioutil.WriteFile(filepath.Join(d, "project"), []byte("a project\n"), 0600), IsNil)
+				c.Assume(r.Add(ctx, "idea/1"), IsNil)
+				c.Assume(r.Add(ctx, "project"), IsNil)
+				c.Assume(r.Commit(ctx, "a project"), IsNil)
+
+				// Work in progress on the project
+				c.Assume(ioutil.WriteFile(filepath.Join(d, "project"), []byte("a modified project\n"), 0600), IsNil)
+				c.Assume(ioutil.WriteFile(filepath.Join(d, "staged"), []byte("a staged file\n"), 0600), IsNil)
+				c.Assume(r.Add(ctx, "staged"), IsNil)
+
+				projectStatus := func() string {
+					o, err := Command(d, "status", "--porcelain").Output()
+					c.Assume(err, IsNil)
+					return string(o)
+				}
+				c.Assume(projectStatus(), Equals, " M project\nA  staged\n")
+
+				journalDir := filepath.Join(d, "log")
+				c.Assume(os.Mkdir(journalDir, 0700), IsNil)
+
+				j, err := Open(journalDir, backend)
+				c.Assume(err, IsNil)
+
+				status, err := j.Status(ctx)
+				c.Assume(err, IsNil)
+				c.Expect(len(status), Equals, 0)
+				c.Expect(IsClean(ctx, journalDir), IsNil)
+				c.Expect(IsClean(ctx, d), Not(IsNil))
+
+				start, err := j.RevParse(ctx, "HEAD")
+				c.Assume(err, IsNil)
+
+				c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "entry"), []byte("an entry\n"), 0600), IsNil)
+				c.Assume(j.Add(ctx, "entry"), IsNil)
+				c.Assume(j.Commit(ctx, "an entry"), IsNil)
+				c.Assume(j.CommitEmpty(ctx, "an empty commit"), IsNil)
+
+				committed, err := Command(d, "log", "--name-only", "--format=%s", "-2").Output()
+				c.Assume(err, IsNil)
+				c.Expect(string(committed), Equals, "an empty commit\nan entry\n\nlog/entry\n")
+				c.Expect(projectStatus(), Equals, " M project\nA  staged\n")
+
+				c.Specify("and will fail to commit without any changes in the journal", func() {
+					c.Expect(j.Commit(ctx, "nothing"), Not(IsNil))
+					c.Expect(projectStatus(), Equals, " M project\nA  staged\n")
+				})
+
+				c.Specify("and will only reset the files in the journal", func() {
+					c.Assume(ioutil.WriteFile(filepath.Join(journalDir, "untracked"), []byte("untracked\n"), 0600), IsNil)
+					c.Assume(j.Reset(ctx, start, HardReset), IsNil)
+
+					hash, err := j.RevParse(ctx, "HEAD")
+					c.Assume(err, IsNil)
+					c.Expect(hash, Equals, start)
+
+					_, err = os.Stat(filepath.Join(journalDir, "entry"))
+					c.Expect(os.IsNotExist(err), IsTrue)
+					c.Expect(projectStatus(), Equals, " M project\nA  staged\n?? log/\n")
+				})
+			})
+		})
+	}
+
+	c.Specify("a repository that signs commits", func() {
+		d, err := ioutil.TempDir("", "git_repository_test")
+		c.Assume(err, IsNil)
+		defer os.RemoveAll(d)
+
+		// A throwaway key that is the only allowed signer
+		key := filepath.Join(d, "signing_key")
+		c.Assume(exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "journal", "-f", key).Run(), IsNil)
+
+		publicKey, err := ioutil.ReadFile(key + ".pub")
+		c.Assume(err, IsNil)
+
+		allowedSigners := filepath.Join(d, "allowed_signers")
+		c.Assume(ioutil.WriteFile(allowedSigners, append([]byte("journal@example.com "), publicKey...), 0600), IsNil)
+
+		signing := Signing{Format: SSHSigning, Key: key, AllowedSigners: allowedSigners}
+
+		for _, backend := range []string{ExecBackend, GoGitBackend} {
+			backend := backend
+
+			c.Specify(fmt.Sprintf("using the %s backend", backend), func() {
+				repoDir := filepath.Join(d, backend)
+				_, err := InitRepository(ctx, repoDir, backend)
+				c.Assume(err, IsNil)
+
+				r, err := OpenWith(repoDir, Options{Backend: backend, Signing: signing})
+				c.Assume(err, IsNil)
+
+				unsigned, err := Open(repoDir, backend)
+				c.Assume(err, IsNil)
+
+				c.Assume(ioutil.WriteFile(filepath.Join(repoDir, "entry"), []byte("an entry\n"), 0600), IsNil)
+				c.Assume(r.Add(ctx, "entry"), IsNil)
+				c.Assume(r.Commit(ctx, "a signed commit"), IsNil)
+				c.Assume(unsigned.CommitEmpty(ctx, "an unsigned commit"), IsNil)
+				c.Assume(r.CommitEmpty(ctx, "a signed empty commit"), IsNil)
+
+				c.Specify("will have signatures git can verify", func() {
+					o, err := Command(repoDir, "-c", "gpg.ssh.allowedSignersFile="+allowedSigners, "log", "--format=%G? %s").Output()
+					c.Assume(err, IsNil)
+					c.Expect(string(o), Equals, "G a signed empty commit\nN an unsigned commit\nG a signed commit\n")
+				})
+
+				c.Specify("will verify the signatures of the commits", func() {
+					verifications, err := r.Verify(ctx, "HEAD", "")
+					c.Assume(err, IsNil)
+					c.Assume(len(verifications), Equals, 3)
+					c.Expect(verifications[0].Subject, Equals, "a signed empty commit")
+					c.Expect(verifications[0].Status, Equals, GoodSignature)
+					c.Expect(verifications[1].Status, Equals, NoSignature)
+					c.Expect(verifications[2].Status, Equals, GoodSignature)
+				})
+
+				c.Specify("will only verify the commits that changed a path", func() {
+					verifications, err := r.Verify(ctx, "HEAD", filepath.Join(repoDir, "entry"))
+					c.Assume(err, IsNil)
+					c.Assume(len(verifications), Equals, 1)
+					c.Expect(verifications[0].Subject, Equals, "a signed commit")
+				})
+
+				c.Specify("will find signatures made by a key that isn't allowed", func() {
+					other := filepath.Join(d, "other_key")
+					c.Assume(exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", other).Run(), IsNil)
+
+					r, err := OpenWith(repoDir, Options{Backend: backend, Signing: Signing{Format: SSHSigning, Key: other, AllowedSigners: allowedSigners}})
+					c.Assume(err, IsNil)
+					c.Assume(r.CommitEmpty(ctx, "signed by another key"), IsNil)
+
+					verifications, err := r.Verify(ctx, "HEAD", "")
+					c.Assume(err, IsNil)
+					c.Assume(len(verifications), Equals, 4)
+					c.Expect(verifications[0].Status, Equals, UntrustedSignature)
+					c.Expect(verifications[0].Status.IsGood(), IsFalse)
+				})
+			})
+		}
+	})
+
+	c.Specify("a repository cannot be opened", func() {
+		c.Specify("with an unknown backend", func() {
+			_, err := Open(os.TempDir(), "svn")
+			c.Expect(err, Equals, UnknownBackendError{"svn"})
+		})
+
+		c.Specify("outside of a repository", func() {
+			d, err := ioutil.TempDir("", "git_repository_test")
+			c.Assume(err, IsNil)
+			defer os.RemoveAll(d)
+
+			for _, backend := range []string{ExecBackend, GoGitBackend} {
+				_, err := Open(d, backend)
+				c.Expect(err, Not(IsNil))
+			}
+		})
+
+		c.Specify("with an unknown signing format", func() {
+			_, err := OpenWith(os.TempDir(), Options{Signing: Signing{Format: "x509"}})
+			c.Expect(IsUnknownSigningFormatError(err), IsTrue)
+		})
+	})
+}
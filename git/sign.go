@@ -0,0 +1,259 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The formats a commit can be signed with
+const (
+	// Signed with `gpg` using an OpenPGP key
+	GPGSigning = "gpg"
+
+	// Signed with `ssh-keygen` using an ssh key
+	SSHSigning = "ssh"
+)
+
+// Configures how commits are signed
+type Signing struct {
+	// The format of the signature, "gpg" or "ssh".
+	// If empty commits aren't signed.
+	Format string `json:"format,omitempty"`
+
+	// The key commits are signed with. For gpg this is a key id, for ssh
+	// the path to a private key. If empty the exec backend uses the
+	// user.signingkey configured in git and the go-git backend can
+	// only sign with gpg's default key.
+	Key string `json:"key,omitempty"`
+
+	// The path to an allowed signers file used to verify ssh signatures.
+	// If empty the exec backend uses the gpg.ssh.allowedSignersFile
+	// configured in git and the go-git backend can't check ssh signatures.
+	AllowedSigners string `json:"allowedSigners,omitempty"`
+}
+
+// Returned when signing is configured with a format that doesn't exist
+type UnknownSigningFormatError struct {
+	Format string
+}
+
+func (e UnknownSigningFormatError) Error() string {
+	return fmt.Sprintf("unknown signing format: %s", e.Format)
+}
+
+func IsUnknownSigningFormatError(err error) bool {
+	_, ok := err.(UnknownSigningFormatError)
+	return ok
+}
+
+// Returns true if commits will be signed
+func (s Signing) IsEnabled() bool { return len(s.Format) != 0 }
+
+// Returns an error if the format doesn't exist
+func (s Signing) Validate() error {
+	switch s.Format {
+	case "", GPGSigning, SSHSigning:
+		return nil
+	}
+	return UnknownSigningFormatError{s.Format}
+}
+
+// Returns the `-c name=value` arguments used to sign
+// and verify commits with `git` in this format
+func (s Signing) gitConfig() []string {
+	args := make([]string, 0, 6)
+
+	switch s.Format {
+	case GPGSigning:
+		args = append(args, "-c", "gpg.format=openpgp")
+	case SSHSigning:
+		args = append(args, "-c", "gpg.format=ssh")
+	}
+
+	if len(s.Key) != 0 {
+		args = append(args, "-c", "user.signingkey="+s.Key)
+	}
+
+	if len(s.AllowedSigners) != 0 {
+		args = append(args, "-c", "gpg.ssh.allowedSignersFile="+s.AllowedSigners)
+	}
+
+	return args
+}
+
+// Signs a commit by executing `gpg` or `ssh-keygen`
+// the same way `git commit -S` does.
+// Implements the go-git Signer interface.
+type programSigner struct {
+	Signing
+}
+
+func (s programSigner) Sign(message io.Reader) ([]byte, error) {
+	var c *exec.Cmd
+
+	switch s.Format {
+	case GPGSigning:
+		args := []string{"--batch", "--detach-sign", "--armor"}
+		if len(s.Key) != 0 {
+			args = append(args, "--local-user", s.Key)
+		}
+		c = exec.Command("gpg", args...)
+
+	case SSHSigning:
+		if len(s.Key) == 0 {
+			return nil, fmt.Errorf("an ssh key must be configured to sign commits")
+		}
+		c = exec.Command("ssh-keygen", "-Y", "sign", "-n", "git", "-f", s.Key)
+
+	default:
+		return nil, UnknownSigningFormatError{s.Format}
+	}
+
+	var stderr bytes.Buffer
+	c.Stdin = message
+	c.Stderr = &stderr
+
+	signature, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error signing commit: %v\n%s", err, stderr.Bytes())
+	}
+
+	return signature, nil
+}
+
+// The result of verifying the signature of a commit.
+// The codes are the same as the %G? format of `git log`.
+type SignatureStatus byte
+
+const (
+	GoodSignature        SignatureStatus = 'G'
+	UntrustedSignature   SignatureStatus = 'U'
+	BadSignature         SignatureStatus = 'B'
+	ExpiredSignature     SignatureStatus = 'X'
+	ExpiredKeySignature  SignatureStatus = 'Y'
+	RevokedKeySignature  SignatureStatus = 'R'
+	UncheckableSignature SignatureStatus = 'E'
+	NoSignature          SignatureStatus = 'N'
+)
+
+// Returns true if the commit has a good signature made by a trusted key
+func (s SignatureStatus) IsGood() bool {
+	return s == GoodSignature
+}
+
+func (s SignatureStatus) String() string {
+	switch s {
+	case GoodSignature:
+		return "good signature"
+	case UntrustedSignature:
+		return "good signature made by a key that isn't trusted"
+	case BadSignature:
+		return "bad signature"
+	case ExpiredSignature:
+		return "good signature that has expired"
+	case ExpiredKeySignature:
+		return "good signature made by an expired key"
+	case RevokedKeySignature:
+		return "good signature made by a revoked key"
+	case UncheckableSignature:
+		return "signature can't be checked"
+	case NoSignature:
+		return "not signed"
+	}
+	return fmt.Sprintf("unknown signature status %q", byte(s))
+}
+
+// A commit returned by Repository.Verify
+type Verification struct {
+	Hash    string
+	Subject string
+	Status  SignatureStatus
+}
+
+// Verifies a signature of the payload by executing
+// `gpg` or `ssh-keygen` the same way `git log --show-signature` does.
+func (s Signing) verify(payload []byte, signature string) (SignatureStatus, error) {
+	sigFile, err := ioutil.TempFile("", "journal_signature_")
+	if err != nil {
+		return UncheckableSignature, err
+	}
+	defer os.Remove(sigFile.Name())
+
+	_, err = sigFile.WriteString(signature)
+	sigFile.Close()
+	if err != nil {
+		return UncheckableSignature, err
+	}
+
+	if strings.HasPrefix(signature, "-----BEGIN SSH SIGNATURE-----") {
+		return s.verifySSH(payload, sigFile.Name())
+	}
+
+	return verifyGPG(payload, sigFile.Name())
+}
+
+func verifyGPG(payload []byte, sigFile string) (SignatureStatus, error) {
+	c := exec.Command("gpg", "--batch", "--status-fd=1", "--verify", sigFile, "-")
+	c.Stdin = bytes.NewReader(payload)
+
+	// gpg exits with an error for any signature that isn't good
+	o, _ := c.Output()
+
+	status := UncheckableSignature
+	for _, line := range strings.Split(string(o), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "[GNUPG:] "))
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "GOODSIG":
+			status = UntrustedSignature
+		case "BADSIG":
+			return BadSignature, nil
+		case "EXPSIG":
+			return ExpiredSignature, nil
+		case "EXPKEYSIG":
+			return ExpiredKeySignature, nil
+		case "REVKEYSIG":
+			return RevokedKeySignature, nil
+		case "TRUST_FULLY", "TRUST_ULTIMATE":
+			if status == UntrustedSignature {
+				status = GoodSignature
+			}
+		}
+	}
+
+	return status, nil
+}
+
+func (s Signing) verifySSH(payload []byte, sigFile string) (SignatureStatus, error) {
+	if len(s.AllowedSigners) == 0 {
+		return UncheckableSignature, nil
+	}
+
+	o, err := exec.Command("ssh-keygen", "-Y", "find-principals", "-f", s.AllowedSigners, "-s", sigFile).Output()
+	principals := strings.Fields(string(o))
+	if err != nil || len(principals) == 0 {
+		// The key isn't an allowed signer, check the signature is valid
+		c := exec.Command("ssh-keygen", "-Y", "check-novalidate", "-n", "git", "-s", sigFile)
+		c.Stdin = bytes.NewReader(payload)
+		if c.Run() != nil {
+			return BadSignature, nil
+		}
+		return UntrustedSignature, nil
+	}
+
+	c := exec.Command("ssh-keygen", "-Y", "verify", "-n", "git", "-f", s.AllowedSigners, "-I", principals[0], "-s", sigFile)
+	c.Stdin = bytes.NewReader(payload)
+	if c.Run() != nil {
+		return BadSignature, nil
+	}
+
+	return GoodSignature, nil
+}
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The trailers `journal new` relates the commits of an entry and its ideas with
+const (
+	// The id of an idea created by the entry
+	IdeaCreatedTrailer = "Idea-Created"
+
+	// The id of an idea updated by the entry
+	IdeaUpdatedTrailer = "Idea-Updated"
+
+	// The path of the entry, relative to the journal's directory
+	EntryTrailer = "Entry"
+)
+
+// A "Key: value" line at the end of a commit message
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func (t Trailer) String() string { return t.Key + ": " + t.Value }
+
+// Returns the commitable with the trailers added after its own
+func WithTrailers(c Commitable, trailers ...Trailer) Commitable {
+	return trailedCommitable{c, trailers}
+}
+
+type trailedCommitable struct {
+	Commitable
+	trailers []Trailer
+}
+
+func (c trailedCommitable) CommitDescription() Description {
+	d := c.Commitable.CommitDescription()
+	d.Trailers = append(append([]Trailer(nil), d.Trailers...), c.trailers...)
+	return d
+}
+
+// Returns the message followed by the trailers. If the message already
+// ends with trailers they're added to them, otherwise they're added in a
+// new paragraph.
+func appendTrailers(msg string, trailers []Trailer) string {
+	if len(trailers) == 0 {
+		return msg
+	}
+
+	lines := make([]string, 0, len(trailers))
+	for _, t := range trailers {
+		lines = append(lines, t.String())
+	}
+
+	separator := "\n\n"
+	if len(ParseTrailers(msg)) > 0 {
+		separator = "\n"
+	}
+
+	return strings.TrimRight(msg, "\n") + separator + strings.Join(lines, "\n") + "\n"
+}
+
+var trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*): (.*)$`)
+
+// Returns the trailers in the last paragraph of a commit message. If a
+// line of the paragraph isn't a trailer the message doesn't have any.
+func ParseTrailers(msg string) []Trailer {
+	paragraphs := strings.Split(strings.TrimRight(msg, "\n"), "\n\n")
+
+	// The subject is never a trailer
+	if len(paragraphs) < 2 {
+		return nil
+	}
+
+	lines := strings.Split(strings.Trim(paragraphs[len(paragraphs)-1], "\n"), "\n")
+	trailers := make([]Trailer, 0, len(lines))
+
+	for _, line := range lines {
+		m := trailerLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+
+		trailers = append(trailers, Trailer{Key: m[1], Value: m[2]})
+	}
+
+	return trailers
+}
+
+// Returns the values of the trailers named key, in the order they're in the message
+func (e LogEntry) Trailers(key string) []string {
+	var values []string
+	for _, t := range ParseTrailers(e.Message) {
+		if t.Key == key {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
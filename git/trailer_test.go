@@ -0,0 +1,60 @@
+package git
+
+import (
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeTrailers(c gospec.Context) {
+	c.Specify("the trailers of a commit", func() {
+		changes := &Changes{Msg: "a title\n\nthe body\n"}
+		trailed := WithTrailers(changes,
+			Trailer{IdeaCreatedTrailer, "27"},
+			Trailer{IdeaUpdatedTrailer, "3"},
+		)
+
+		c.Specify("will be added to the end of its message", func() {
+			var t MsgTemplates
+
+			msg, err := t.Render(trailed)
+			c.Expect(err, IsNil)
+			c.Expect(msg, Equals, "a title\n\nthe body\n\nIdea-Created: 27\nIdea-Updated: 3\n")
+		})
+
+		c.Specify("will be added to the end of a rendered message", func() {
+			t, err := ParseMsgTemplates(map[string]string{DefaultCommit: `journal: {{.Msg}}`})
+			c.Assume(err, IsNil)
+
+			msg, err := t.Render(WithTrailers(trailed, Trailer{EntryTrailer, "entry/2015-01-01-0000-UTC"}))
+			c.Expect(err, IsNil)
+			c.Expect(msg, Equals, "journal: a title\n\nthe body\n\nIdea-Created: 27\nIdea-Updated: 3\nEntry: entry/2015-01-01-0000-UTC\n")
+		})
+
+		c.Specify("will be added to the trailers a rendered message ends with", func() {
+			t, err := ParseMsgTemplates(map[string]string{DefaultCommit: "{{.Msg}}\nSigned-off-by: A Writer <writer@example.com>\n"})
+			c.Assume(err, IsNil)
+
+			msg, err := t.Render(trailed)
+			c.Expect(err, IsNil)
+			c.Expect(msg, Equals, "a title\n\nthe body\n\nSigned-off-by: A Writer <writer@example.com>\nIdea-Created: 27\nIdea-Updated: 3\n")
+			c.Expect(len(ParseTrailers(msg)), Equals, 3)
+		})
+
+		c.Specify("can be parsed from its message", func() {
+			e := LogEntry{Message: "a title\n\nthe body\n\nIdea-Updated: 3\nIdea-Updated: 5\nEntry: entry/2015-01-01-0000-UTC"}
+			c.Expect(e.Trailers(IdeaUpdatedTrailer), ContainsExactly, []string{"3", "5"})
+			c.Expect(e.Trailers(EntryTrailer), ContainsExactly, []string{"entry/2015-01-01-0000-UTC"})
+			c.Expect(e.Trailers(IdeaCreatedTrailer), ContainsExactly, []string{})
+		})
+
+		c.Specify("won't be parsed", func() {
+			c.Specify("from the subject", func() {
+				c.Expect(len(ParseTrailers("Entry: a title")), Equals, 0)
+			})
+
+			c.Specify("from a paragraph that isn't only trailers", func() {
+				c.Expect(len(ParseTrailers("a title\n\nIdea-Updated: 3\nand some text")), Equals, 0)
+			})
+		})
+	})
+}
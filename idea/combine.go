@@ -0,0 +1,250 @@
+package idea
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/lock"
+)
+
+// Prefix of the line appended to the body of an
+// idea when it is merged into another idea
+const MergedIntoPrefix = "Merged-Into:"
+
+// Returned when merging an idea that has already been merged
+type AlreadyMergedError struct {
+	Id uint
+}
+
+func (e AlreadyMergedError) Error() string {
+	return fmt.Sprintf("idea %d has already been merged into another idea", e.Id)
+}
+
+func IsAlreadyMergedError(err error) bool {
+	_, ok := err.(AlreadyMergedError)
+	return ok
+}
+
+var ErrMergeIntoSelf = errors.New("cannot merge an idea into itself")
+
+// Appends the body of src to the body of dst, in a paragraph beginning
+// with the heading if it isn't empty. The tasks and dates that dst
+// already has are not copied from src.
+func combineBodies(dst, src Idea, heading string) (string, error) {
+	dstSchedule, err := dst.Schedule()
+	if err != nil {
+		return "", err
+	}
+
+	dstTasks := make(map[string]bool, len(dstSchedule.Tasks))
+	for _, task := range dstSchedule.Tasks {
+		dstTasks[task.Text] = true
+	}
+
+	b := bytes.NewBufferString(dst.Body)
+	if len(dst.Body) > 0 && !strings.HasSuffix(dst.Body, "\n") {
+		b.WriteByte('\n')
+	}
+
+	if len(dst.Body) > 0 {
+		b.WriteByte('\n')
+	}
+
+	if len(heading) > 0 {
+		fmt.Fprintln(b, heading)
+	}
+
+	for _, line := range strings.SplitAfter(src.Body, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, DuePrefix) && !dstSchedule.Due.IsZero():
+			continue
+
+		case strings.HasPrefix(trimmed, DeferUntilPrefix) && !dstSchedule.DeferUntil.IsZero():
+			continue
+
+		case isTaskLine(trimmed):
+			task, err := parseTask(trimmed)
+			if err != nil {
+				return "", err
+			}
+
+			if dstTasks[task.Text] {
+				continue
+			}
+		}
+
+		b.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Returns the existing idea with the body of a new idea that duplicates
+// it appended, so the existing idea's body isn't lost when the new idea
+// is saved with its id. The existing idea keeps its name and takes the
+// status of the new idea. The tasks and dates that the existing idea
+// already has are not copied from the new idea.
+func ReuseIdea(existing, duplicate Idea) (Idea, error) {
+	if len(strings.TrimSpace(duplicate.Body)) > 0 && !strings.Contains(existing.Body, duplicate.Body) {
+		body, err := combineBodies(existing, duplicate, "")
+		if err != nil {
+			return Idea{}, err
+		}
+		existing.Body = body
+	}
+
+	existing.Status = duplicate.Status
+
+	return existing, nil
+}
+
+// Merges the idea with srcId into the idea with dstId and returns
+// a commitable containing all changes. The body and tasks of the source
+// are appended to the destination and the source is marked as merged
+// and removed from the active index. If the source's status can't be
+// changed to merged this method will return an InvalidTransitionError.
+func (d DirectoryStore) MergeInto(srcId, dstId uint) (git.Commitable, error) {
+	if srcId == dstId {
+		return nil, ErrMergeIntoSelf
+	}
+
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	src, err := d.IdeaById(srcId)
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := d.IdeaById(dstId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idea := range []Idea{src, dst} {
+		if idea.Status == IS_Merged {
+			return nil, AlreadyMergedError{idea.Id}
+		}
+	}
+
+	err = d.statuses.CanTransition(src.Status, IS_Merged)
+	if err != nil {
+		if e, ok := err.(InvalidTransitionError); ok {
+			e.Id = src.Id
+			return nil, e
+		}
+		return nil, err
+	}
+
+	dst.Body, err = combineBodies(dst, src, fmt.Sprintf("Merged from [%d] %s", src.Id, src.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(src.Body) > 0 && !strings.HasSuffix(src.Body, "\n") {
+		src.Body += "\n"
+	}
+	src.Body += fmt.Sprintf("%s %d\n", MergedIntoPrefix, dstId)
+	src.Status = IS_Merged
+
+	changes := git.NewChangesIn(d.root)
+
+	for _, idea := range []Idea{src, dst} {
+		err := writeIdeaTo(d.root, idea)
+		if err != nil {
+			return nil, err
+		}
+		d.loaded[idea.Id] = idea
+		changes.Add(git.ChangedFile(fmt.Sprint(idea.Id)))
+	}
+
+	activeIds, err := activeIdeasIn(d.root)
+	if err != nil {
+		return nil, err
+	}
+
+	if newActiveIds := withActiveId(append([]uint(nil), activeIds...), srcId, false); len(newActiveIds) != len(activeIds) {
+		err := writeActiveIdsTo(d.root, newActiveIds)
+		if err != nil {
+			return nil, err
+		}
+		changes.Add(git.ChangedFile("active"))
+	}
+
+	changes.Msg = fmt.Sprintf("idea - merged - %d into %d", srcId, dstId)
+
+	return changes, nil
+}
+
+// Saves the original idea, with the part of its body that was split out
+// removed, and creates the split idea with the next available id.
+// Returns a commitable containing the changes to both ideas.
+func (d DirectoryStore) SplitIdea(ctx context.Context, original Idea, split *Idea) (git.Commitable, error) {
+	if original.Id == 0 {
+		return nil, errors.New("cannot split an idea that doesn't have an id")
+	}
+
+	if split.Id != 0 {
+		return nil, ErrIdeaExists
+	}
+
+	// Validate the split idea before the original is modified
+	if _, exists := d.statuses.Lookup(split.Status); !exists {
+		return nil, UnknownStatusError{split.Status}
+	}
+
+	if _, err := split.Schedule(); err != nil {
+		return nil, err
+	}
+
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	changes := git.NewChangesIn(d.root)
+	isChanged := make(map[string]bool, 4)
+
+	updated, err := d.UpdateIdea(ctx, original)
+	if err != nil && err != ErrIdeaNotModified {
+		return nil, err
+	}
+
+	created, err := d.saveNewIdea(split)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range []git.Commitable{updated, created} {
+		if c == nil {
+			continue
+		}
+
+		for _, change := range c.Changes() {
+			if !isChanged[change.Filepath()] {
+				isChanged[change.Filepath()] = true
+				changes.Add(change)
+			}
+		}
+	}
+
+	changes.Msg = fmt.Sprintf("idea - split - %d from %d", split.Id, original.Id)
+
+	return changes, nil
+}
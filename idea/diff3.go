@@ -0,0 +1,148 @@
+package idea
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Splits text into lines, each ending with its newline
+// except for a last line that doesn't have one
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Returns the index of the line in b that each line in a is matched
+// with by their longest common subsequence, or -1 if it isn't matched
+func matchLines(a, b []string) []int {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matches[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return matches
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Writes the lines of one side of a conflict, ending the
+// last line with a newline so it's followed by a marker
+func writeConflictLines(b *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		b.WriteByte('\n')
+	}
+}
+
+// Three-way merge of the lines of text, like `git merge-file`. The
+// lines changed on only one side are taken from that side. The lines
+// changed differently on both sides are written between conflict markers
+// labeled with ourLabel and theirLabel. Returns the merged text and
+// true if it contains conflicts.
+func mergeLines(ours, base, theirs, ourLabel, theirLabel string) (string, bool) {
+	o, b, t := splitLines(ours), splitLines(base), splitLines(theirs)
+	inOurs, inTheirs := matchLines(b, o), matchLines(b, t)
+
+	merged := bytes.NewBuffer(nil)
+	hasConflicts := false
+
+	// Writes the lines that are between the lines every side shares
+	writeChunk := func(o, b, t []string) {
+		switch {
+		case equalLines(o, b), equalLines(o, t):
+			o = t
+		case equalLines(t, b):
+		default:
+			// The lines at the start and end that both sides
+			// changed the same aren't part of the conflict
+			prefix := 0
+			for prefix < len(o) && prefix < len(t) && o[prefix] == t[prefix] {
+				prefix++
+			}
+
+			suffix := 0
+			for suffix < len(o)-prefix && suffix < len(t)-prefix && o[len(o)-1-suffix] == t[len(t)-1-suffix] {
+				suffix++
+			}
+
+			for _, line := range o[:prefix] {
+				merged.WriteString(line)
+			}
+
+			merged.WriteString("<<<<<<< " + ourLabel + "\n")
+			writeConflictLines(merged, o[prefix:len(o)-suffix])
+			merged.WriteString("=======\n")
+			writeConflictLines(merged, t[prefix:len(t)-suffix])
+			merged.WriteString(">>>>>>> " + theirLabel + "\n")
+
+			o = o[len(o)-suffix:]
+			hasConflicts = true
+		}
+
+		for _, line := range o {
+			merged.WriteString(line)
+		}
+	}
+
+	oi, bi, ti := 0, 0, 0
+	for i := range b {
+		if inOurs[i] < 0 || inTheirs[i] < 0 {
+			continue
+		}
+
+		writeChunk(o[oi:inOurs[i]], b[bi:i], t[ti:inTheirs[i]])
+		merged.WriteString(b[i])
+		oi, bi, ti = inOurs[i]+1, i+1, inTheirs[i]+1
+	}
+
+	writeChunk(o[oi:], b[bi:], t[ti:])
+
+	return merged.String(), hasConflicts
+}
@@ -0,0 +1,66 @@
+package idea
+
+import (
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeMergeLines(c gospec.Context) {
+	c.Specify("a three-way merge of lines", func() {
+		base := "line 1\nline 2\nline 3\nline 4\n"
+
+		merge := func(ours, theirs string) (string, bool) {
+			return mergeLines(ours, base, theirs, "ours", "theirs")
+		}
+
+		c.Specify("will take the lines changed on either side", func() {
+			merged, hasConflicts := merge("line 1 edited\nline 2\nline 3\nline 4\n", "line 1\nline 2\nline 3\nline 4 edited\n")
+			c.Expect(hasConflicts, IsFalse)
+			c.Expect(merged, Equals, "line 1 edited\nline 2\nline 3\nline 4 edited\n")
+		})
+
+		c.Specify("will take the lines added and removed on either side", func() {
+			merged, hasConflicts := merge("line 0\nline 1\nline 2\nline 3\nline 4\n", "line 1\nline 3\nline 4\nline 5")
+			c.Expect(hasConflicts, IsFalse)
+			c.Expect(merged, Equals, "line 0\nline 1\nline 3\nline 4\nline 5")
+		})
+
+		c.Specify("will take the lines changed the same on both sides", func() {
+			merged, hasConflicts := merge("line 1\nline 2 edited\nline 3\nline 4\n", "line 1\nline 2 edited\nline 3\nline 4\n")
+			c.Expect(hasConflicts, IsFalse)
+			c.Expect(merged, Equals, "line 1\nline 2 edited\nline 3\nline 4\n")
+		})
+
+		c.Specify("will mark the lines changed differently on both sides", func() {
+			merged, hasConflicts := merge("line 1\nline 2 edited\nline 3\nline 4\n", "line 1\nline 2 modified\nline 3\nline 4\n")
+			c.Expect(hasConflicts, IsTrue)
+			c.Expect(merged, Equals, `line 1
+<<<<<<< ours
+line 2 edited
+=======
+line 2 modified
+>>>>>>> theirs
+line 3
+line 4
+`)
+		})
+
+		c.Specify("will leave the lines added the same on both sides out of a conflict", func() {
+			merged, hasConflicts := merge(base+"line 5\nline 6 edited\nline 7\n", base+"line 5\nline 6 modified\nline 7")
+			c.Expect(hasConflicts, IsTrue)
+			c.Expect(merged, Equals, `line 1
+line 2
+line 3
+line 4
+line 5
+<<<<<<< ours
+line 6 edited
+line 7
+=======
+line 6 modified
+line 7
+>>>>>>> theirs
+`)
+		})
+	})
+}
@@ -0,0 +1,205 @@
+package idea
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The canonical idea format is
+//
+//	## [status] [id] name
+//	body
+//
+// The id is omitted if the idea doesn't have one.
+//
+// A name is escaped by writing `\` as `\\`, a newline as `\n`, a carriage
+// return as `\r` and a leading `[` as `\[`.
+//
+// A line of the body is escaped with a leading `\` if it starts with `\`,
+// if it would be parsed as an idea header or a timestamp, or if it opens
+// a fenced code block that isn't closed within the body.
+// A body that doesn't end with a newline is terminated by a line
+// containing only `\`.
+//
+// Within an entry the ideas are separated by a blank line
+// that isn't part of the body of the idea before it.
+
+// Returned when an idea's status can't be written into an idea header
+var ErrInvalidStatus = errors.New("idea status must not be empty or contain whitespace or []")
+
+// The line that terminates a body that doesn't end with a newline
+const noTrailingNewline = "\\\n"
+
+// Encode the idea in the canonical idea format
+func Encode(idea Idea) ([]byte, error) {
+	if len(idea.Status) == 0 || strings.ContainsAny(idea.Status, "[] \t\r\n") {
+		return nil, ErrInvalidStatus
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0, len(idea.Body)+64))
+
+	fmt.Fprintf(b, "## [%s] ", idea.Status)
+	if idea.Id != 0 {
+		fmt.Fprintf(b, "[%d] ", idea.Id)
+	}
+	b.WriteString(encodeName(idea.Name))
+	b.WriteByte('\n')
+
+	b.WriteString(encodeBody(idea.Body))
+
+	return b.Bytes(), nil
+}
+
+// Decode an idea that was encoded in the canonical idea format
+func Decode(data []byte) (Idea, error) {
+	scanner := NewIdeaScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		if scanner.Err() != nil {
+			return Idea{}, scanner.Err()
+		}
+		return Idea{}, errors.New("no idea was found")
+	}
+
+	return *scanner.Idea(), nil
+}
+
+var nameEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+func encodeName(name string) string {
+	name = nameEscaper.Replace(name)
+	if strings.HasPrefix(name, "[") {
+		name = `\` + name
+	}
+	return name
+}
+
+func decodeName(name string) string {
+	if strings.IndexByte(name, '\\') < 0 {
+		return name
+	}
+
+	b := make([]byte, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+1 < len(name) {
+			switch name[i+1] {
+			case '\\':
+				b = append(b, '\\')
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case '[':
+				b = append(b, '[')
+			default:
+				// Not an escape sequence
+				b = append(b, '\\')
+				continue
+			}
+
+			i++
+			continue
+		}
+
+		b = append(b, name[i])
+	}
+
+	return string(b)
+}
+
+// Returns the index of the line that opens a fenced code
+// block that isn't closed by the last line, or -1
+func unclosedFenceIn(lines []string, escaped []bool) int {
+	fence, opened := "", -1
+
+	for i, line := range lines {
+		if escaped[i] {
+			continue
+		}
+
+		marker := fenceOf(line)
+		switch {
+		case fence == "" && marker != "":
+			fence, opened = marker, i
+
+		case fence != "" && strings.HasPrefix(marker, fence) && strings.TrimSpace(line) == marker:
+			fence, opened = "", -1
+		}
+	}
+
+	return opened
+}
+
+func encodeBody(body string) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	lines := strings.SplitAfter(body, "\n")
+
+	hasTrailingNewline := lines[len(lines)-1] == ""
+	if hasTrailingNewline {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+
+	escaped := make([]bool, len(lines))
+	for i, line := range lines {
+		escaped[i] = strings.HasPrefix(line, `\`) || isHeader(line) || isTimestamp(line)
+	}
+
+	// Escaping an unclosed fence can reveal another
+	for i := unclosedFenceIn(lines, escaped); i >= 0; i = unclosedFenceIn(lines, escaped) {
+		escaped[i] = true
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0, len(body)+16))
+	for i, line := range lines {
+		if escaped[i] {
+			b.WriteByte('\\')
+		}
+		b.WriteString(line)
+	}
+
+	if !hasTrailingNewline {
+		b.WriteString(noTrailingNewline)
+	}
+
+	return b.String()
+}
+
+// Decodes the lines of a body. If the body is followed by another
+// idea or a timestamp the blank line separating them is removed.
+func decodeBody(lines []string, isSeparated bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	// A final line without a newline wasn't written by the encoder
+	if last := lines[len(lines)-1]; !strings.HasSuffix(last, "\n") {
+		lines[len(lines)-1] = last + "\n"
+	}
+
+	if isSeparated && len(strings.TrimSpace(lines[len(lines)-1])) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	hasTrailingNewline := true
+	if len(lines) > 0 && lines[len(lines)-1] == noTrailingNewline {
+		hasTrailingNewline = false
+		lines = lines[:len(lines)-1]
+	}
+
+	b := bytes.NewBuffer(make([]byte, 0, 1024))
+	for _, line := range lines {
+		b.WriteString(strings.TrimPrefix(line, `\`))
+	}
+
+	if !hasTrailingNewline {
+		b.Truncate(len(bytes.TrimSuffix(b.Bytes(), []byte("\n"))))
+	}
+
+	return b.String()
+}
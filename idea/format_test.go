@@ -0,0 +1,117 @@
+package idea
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+// Fragments that are combined into names and bodies that
+// contain everything the idea format needs to escape
+var formatFragments = []string{
+	"a", "some text", " ", "\t", "\n", "\n\n", "\r\n", "\\", "[", "]", "[12]", "[] ",
+	"## [", "## [active] [1] name", "```", "~~~", "````", "```go",
+	"Sun Jan 26 15:03:44 EST 2014",
+}
+
+func randomText(r *rand.Rand, maxFragments int) string {
+	n := r.Intn(maxFragments + 1)
+	parts := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		parts = append(parts, formatFragments[r.Intn(len(formatFragments))])
+	}
+	return strings.Join(parts, "")
+}
+
+func DescribeIdeaFormat(c gospec.Context) {
+	c.Specify("the idea format", func() {
+		ideas := []Idea{
+			{IS_Active, 0, "", ""},
+			{IS_Active, 1, "name", "\n"},
+			{IS_Active, 2, "name", "no trailing newline"},
+			{IS_Active, 3, "[12] name", "\n\nblank lines\n\n\n"},
+			{IS_Active, 0, "[] name ", "  indented\n"},
+			{IS_Inactive, 4, "a\\name\nwith\rescapes", "\\\n\\"},
+			{IS_Completed, 5, "name", "## [active] [1] not an idea\nSun Jan 26 15:03:44 EST 2014\n"},
+			{IS_Active, 6, "name", "```\nan unclosed fence\n~~~\n## [active] [1] not an idea\n"},
+			{IS_Active, 7, "name", "```go\n## [active] [1] code\n```\n"},
+		}
+
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 500; i++ {
+			ideas = append(ideas, Idea{
+				Status: IS_Active,
+				Id:     uint(r.Intn(3)),
+				Name:   randomText(r, 3),
+				Body:   randomText(r, 8),
+			})
+		}
+
+		c.Specify("will decode an encoded idea unmodified", func() {
+			for _, idea := range ideas {
+				data, err := Encode(idea)
+				c.Assume(err, IsNil)
+
+				actual, err := Decode(data)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, idea)
+			}
+		})
+
+		c.Specify("will decode the ideas in an entry unmodified", func() {
+			entry := bytes.NewBufferString("Sun Jan 26 15:03:44 EST 2014\n\n# Title\n")
+			for _, idea := range ideas {
+				data, err := Encode(idea)
+				c.Assume(err, IsNil)
+
+				entry.WriteString("\n")
+				entry.Write(data)
+			}
+			entry.WriteString("\nMon Jan 27 15:03:44 EST 2014\n")
+
+			scanner := NewIdeaScanner(entry)
+			for _, idea := range ideas {
+				c.Assume(scanner.Scan(), IsTrue)
+				c.Expect(*scanner.Idea(), Equals, idea)
+			}
+
+			c.Expect(scanner.Scan(), IsFalse)
+			c.Expect(scanner.Err(), IsNil)
+		})
+
+		c.Specify("will escape", func() {
+			encode := func(idea Idea) string {
+				data, err := Encode(idea)
+				c.Assume(err, IsNil)
+				return string(data)
+			}
+
+			c.Specify("a body without a trailing newline", func() {
+				c.Expect(encode(Idea{IS_Active, 1, "name", "body"}), Equals, "## [active] [1] name\nbody\n\\\n")
+			})
+
+			c.Specify("a body line that would be parsed", func() {
+				c.Expect(encode(Idea{IS_Active, 1, "name", "## [active] a\n\\b\nSun Jan 26 15:03:44 EST 2014\n"}), Equals,
+					"## [active] [1] name\n\\## [active] a\n\\\\b\n\\Sun Jan 26 15:03:44 EST 2014\n")
+			})
+
+			c.Specify("an unclosed fence", func() {
+				c.Expect(encode(Idea{IS_Active, 1, "name", "```\ncode\n```\n```\n"}), Equals, "## [active] [1] name\n```\ncode\n```\n\\```\n")
+			})
+
+			c.Specify("a name that would be parsed", func() {
+				c.Expect(encode(Idea{IS_Active, 0, "[1] a\\b\nc", ""}), Equals, "## [active] \\[1] a\\\\b\\nc\n")
+			})
+		})
+
+		c.Specify("will not encode an invalid status", func() {
+			for _, status := range []string{"", "in active", "[active]", "active\n"} {
+				_, err := Encode(Idea{status, 1, "name", "body\n"})
+				c.Expect(err, Equals, ErrInvalidStatus)
+			}
+		})
+	})
+}
@@ -5,19 +5,15 @@
 package idea
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
-	"fmt"
 	"io"
-	"io/ioutil"
+	"strconv"
 	"strings"
-	"text/template"
-	"time"
 )
 
 const (
-	// Valid Idea.Status values
+	// The default Idea.Status values, see DefaultStatuses
 	IS_Active    = "active"
 	IS_Inactive  = "inactive"
 	IS_Completed = "completed"
@@ -30,25 +26,20 @@ type Idea struct {
 	Body   string
 }
 
-var ideaTmpl = template.Must(template.New("idea").Parse(
-	`## [{{.Status}}] {{if .Id}}[{{.Id}}] {{end}}{{.Name}}
-{{.Body}}`))
-
 // An implementation of io.Reader for Idea
 type IdeaReader struct {
 	buf io.Reader
 }
 
-// Create an io.Reader with idea
+// Create an io.Reader with idea encoded in the canonical idea format.
+// Reading the idea back with an IdeaScanner will return an identical Idea.
 func NewIdeaReader(idea Idea) (*IdeaReader, error) {
-	b := bytes.NewBuffer(make([]byte, 0, 1024))
-
-	err := ideaTmpl.Execute(b, idea)
+	data, err := Encode(idea)
 	if err != nil {
 		return nil, err
 	}
 
-	return &IdeaReader{b}, nil
+	return &IdeaReader{bytes.NewReader(data)}, nil
 }
 
 func (r *IdeaReader) Read(b []byte) (n int, err error) {
@@ -57,9 +48,9 @@ func (r *IdeaReader) Read(b []byte) (n int, err error) {
 
 // Used to scan Idea's from an io.Reader
 type IdeaScanner struct {
-	scanner *bufio.Scanner
+	tokenizer *Tokenizer
 
-	nextHeader []byte
+	nextHeader *Token
 
 	lastIdea  *Idea
 	lastError error
@@ -67,9 +58,7 @@ type IdeaScanner struct {
 
 // Create an IdeaScanner using an io.Reader
 func NewIdeaScanner(r io.Reader) *IdeaScanner {
-	scanner := bufio.NewScanner(r)
-	scanner.Split(ScanLines)
-	return &IdeaScanner{scanner, nil, nil, nil}
+	return &IdeaScanner{NewTokenizer(r), nil, nil, nil}
 }
 
 // A ScanLines implementation that leaves the '\n' character in the token.
@@ -91,115 +80,153 @@ func ScanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	return 0, nil, nil
 }
 
+// An error in an idea header and the column it was found at
+type headerError struct {
+	column int
+	err    error
+}
+
 func parseHeader(raw string) (status string, id uint, name string, err error) {
-	_, err = fmt.Fscanf(strings.NewReader(raw), "## %s [%d] %s", &status, &id, &name)
-	if err != nil {
-		switch err.Error() {
-		default:
-			return
-		case "expected integer":
-			_, err = fmt.Fscanf(strings.NewReader(raw), "## %s [] %s", &status, &name)
-		case "input does not match format":
-			_, err = fmt.Fscanf(strings.NewReader(raw), "## %s %s", &status, &name)
-		}
+	status, id, name, hErr := parseHeaderAt(raw)
+	if hErr != nil {
+		return "", 0, "", hErr.err
+	}
+	return
+}
 
-		if err != nil {
-			return
-		}
+var (
+	errHeaderPrefix   = errors.New("invalid idea header: must begin with `## `")
+	errHeaderStatus   = errors.New("invalid idea header: status must be wrapped w/ []")
+	errHeaderSpace    = errors.New("invalid idea header: expected a space after the status")
+	errHeaderIdFormat = errors.New("invalid idea header: id must be a positive integer")
+)
+
+// Parses `## [status] [id] name` where the id is optional
+// and may be empty. Columns in the returned error start at 1.
+func parseHeaderAt(raw string) (status string, id uint, name string, hErr *headerError) {
+	line := strings.TrimRight(raw, "\r\n")
+
+	if !strings.HasPrefix(line, "## ") {
+		return "", 0, "", &headerError{1, errHeaderPrefix}
 	}
+	i := len("## ")
 
-	if status[0] != '[' && status[len(status)-1] != ']' {
-		err = errors.New("invalid idea header: status must be wrapped w/ []")
-		return
+	// [status]
+	end := strings.IndexByte(line[i:], ']')
+	if !strings.HasPrefix(line[i:], "[") || end < 0 {
+		return "", 0, "", &headerError{i + 1, errHeaderStatus}
 	}
+	status = line[i+1 : i+end]
+	i += end + 1
 
-	status = strings.Trim(status, "[]")
+	if len(status) == 0 || strings.ContainsAny(status, "[ \t") {
+		return "", 0, "", &headerError{i - end, errHeaderStatus}
+	}
 
-	if strings.Index(raw, name) == strings.Index(raw, status) {
-		// Strip off the `## [status]` portion from string
-		raw = raw[strings.Index(raw, status)+len(status)+1:]
+	// An idea without a name
+	if i == len(line) {
+		return status, 0, "", nil
 	}
-	name = raw[strings.Index(raw, name):]
-	name = strings.TrimRight(name, "\n")
 
-	return
+	if !strings.HasPrefix(line[i:], " ") {
+		return "", 0, "", &headerError{i + 1, errHeaderSpace}
+	}
+	i++
+
+	// [id] or []
+	if strings.HasPrefix(line[i:], "[") {
+		end := strings.IndexByte(line[i:], ']')
+		rest := line[i+end+1:]
+		if end > 0 && isDigits(line[i+1:i+end]) && (len(rest) == 0 || rest[0] == ' ') {
+			if end > 1 {
+				n, err := strconv.ParseUint(line[i+1:i+end], 10, 0)
+				if err != nil || n == 0 {
+					return "", 0, "", &headerError{i + 2, errHeaderIdFormat}
+				}
+				id = uint(n)
+			}
+
+			i += end + 1
+			if len(rest) > 0 {
+				i++
+			}
+		}
+	}
+
+	return status, id, decodeName(line[i:]), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // Scans through the io.Reader for the next Idea
 func (s *IdeaScanner) Scan() bool {
-	sbuf := bytes.NewBuffer(make([]byte, 0, 4096))
+	if s.lastError != nil {
+		return false
+	}
 
 	// Scan the Header
-	if s.nextHeader != nil {
-		_, err := sbuf.Write(s.nextHeader)
-		if err != nil {
-			s.lastError = err
-			return false
-		}
-	} else {
-		for s.scanner.Scan() {
-			line := s.scanner.Text()
-
-			if i := strings.Index(line, "## ["); i >= 0 {
-				_, err := sbuf.Write(s.scanner.Bytes())
-				if err != nil {
-					s.lastError = err
-					return false
-				}
+	if s.nextHeader == nil {
+		for s.tokenizer.Scan() {
+			token := s.tokenizer.Token()
 
-				goto scanBody
+			if token.Kind == TimestampToken {
+				break
+			}
+
+			if token.Kind == HeaderToken {
+				s.nextHeader = &token
+				break
 			}
 		}
 
-		// Scanned all the lines and didn't find a header
-		s.lastError = s.scanner.Err()
-		return false
+		if s.nextHeader == nil {
+			// Scanned all the lines and didn't find a header
+			s.lastError = s.tokenizer.Err()
+			return false
+		}
 	}
 
-scanBody:
+	header := *s.nextHeader
 	s.nextHeader = nil
 
-	for s.scanner.Scan() {
-		line := s.scanner.Text()
+	// Parse the Status, Id, Name
+	status, id, name, hErr := parseHeaderAt(header.Text)
+	if hErr != nil {
+		s.lastError = ParseError{header.Line, hErr.column, hErr.err}
+		return false
+	}
+
+	// Scan the Body
+	lines := make([]string, 0, 16)
+	isSeparated := false
+
+	for s.tokenizer.Scan() {
+		token := s.tokenizer.Token()
 
 		// Look for the start of another Idea
-		if i := strings.Index(line, "## ["); i >= 0 {
-			s.nextHeader = s.scanner.Bytes()
+		if token.Kind == HeaderToken {
+			s.nextHeader = &token
+			isSeparated = true
 			break
 		}
 
 		// Look for the ClosedAt timestamp
-		if _, err := time.Parse(time.UnixDate, line[:len(line)-1]); err == nil {
+		if token.Kind == TimestampToken {
+			isSeparated = true
 			break
 		}
 
-		// Add this line to the body
-		if _, err := sbuf.Write(s.scanner.Bytes()); err != nil {
-			s.lastError = err
-			return false
-		}
-	}
-
-	pbuf := bufio.NewReader(bytes.NewReader(sbuf.Bytes()))
-
-	// Grab the complete header line as a string
-	lineBytes, err := pbuf.ReadBytes('\n')
-	if err != nil {
-		s.lastError = err
-		return false
+		lines = append(lines, token.Text)
 	}
-	line := string(lineBytes)
 
-	// Parse the Status, Id, Name
-	status, id, name, err := parseHeader(line)
-	if err != nil {
-		s.lastError = err
-		return false
-	}
-
-	// Parse the Body
-	bodyBytes, err := ioutil.ReadAll(pbuf)
-	if err != nil {
+	if err := s.tokenizer.Err(); err != nil {
 		s.lastError = err
 		return false
 	}
@@ -208,7 +235,7 @@ scanBody:
 		Status: status,
 		Id:     id,
 		Name:   name,
-		Body:   string(bytes.TrimSpace(bodyBytes)) + "\n",
+		Body:   decodeBody(lines, isSeparated),
 	}
 
 	return true
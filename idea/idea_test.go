@@ -134,6 +134,65 @@ in the body of this Idea.
 			})
 		})
 
+		c.Specify("can be scanned from markdown", func() {
+			scanAll := func(data string) ([]Idea, error) {
+				iscan := NewIdeaScanner(strings.NewReader(data))
+
+				ideas := make([]Idea, 0, 2)
+				for iscan.Scan() {
+					ideas = append(ideas, *iscan.Idea())
+				}
+				return ideas, iscan.Err()
+			}
+
+			c.Specify("and will not start an idea within a fenced code block", func() {
+				ideas, err := scanAll("## [active] [1] An Idea\n" +
+					"```markdown\n" +
+					"## [active] [2] Not an Idea\n" +
+					"````\n" +
+					"~~~\n" +
+					"```\n" +
+					"## [active] [3] Still not an Idea\n" +
+					"~~~\n" +
+					"## [active] [4] Another Idea\n" +
+					"body\n")
+				c.Assume(err, IsNil)
+				c.Assume(len(ideas), Equals, 2)
+
+				c.Expect(ideas[0].Body, Equals, "```markdown\n## [active] [2] Not an Idea\n````\n~~~\n```\n## [active] [3] Still not an Idea\n~~~\n")
+				c.Expect(ideas[1].Id, Equals, uint(4))
+			})
+
+			c.Specify("and will not start an idea in the middle of a line", func() {
+				ideas, err := scanAll("## [active] [1] An Idea\nwrite a header like ## [status] name\n")
+				c.Assume(err, IsNil)
+				c.Assume(len(ideas), Equals, 1)
+				c.Expect(ideas[0].Body, Equals, "write a header like ## [status] name\n")
+			})
+
+			c.Specify("and will only end the body at a timestamp on the last line", func() {
+				ideas, err := scanAll(`## [active] [1] An Idea
+Sun Jan 26 15:03:44 EST 2014
+is when this happened
+
+Mon Jan 27 15:03:44 EST 2014
+
+`)
+				c.Assume(err, IsNil)
+				c.Assume(len(ideas), Equals, 1)
+				c.Expect(ideas[0].Body, Equals, "Sun Jan 26 15:03:44 EST 2014\nis when this happened\n")
+			})
+
+			c.Specify("and will fail with the position of an invalid header", func() {
+				_, err := scanAll("some text\n\n## [active] [1] An Idea\nbody\n## [active]An Idea\n")
+				c.Expect(err, Equals, ParseError{5, 12, errHeaderSpace})
+				c.Expect(err.Error(), Equals, "line 5, column 12: invalid idea header: expected a space after the status")
+
+				_, err = scanAll("## [active [1] An Idea\n")
+				c.Expect(err, Equals, ParseError{1, 4, errHeaderStatus})
+			})
+		})
+
 		c.Specify("can be scanned from an idea file", func() {
 			ideaFiles := []string{
 				`## [active] active idea
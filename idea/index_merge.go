@@ -0,0 +1,75 @@
+package idea
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// Merges the active index from both sides of a merge. The ids activated on
+// either side are kept in the order of our side followed by the ids only
+// activated on their side. An id removed on either side is removed.
+func MergeActiveIndex(base, ours, theirs []byte) ([]byte, error) {
+	baseIds, err := parseActiveIds(base)
+	if err != nil {
+		return nil, err
+	}
+
+	ourIds, err := parseActiveIds(ours)
+	if err != nil {
+		return nil, err
+	}
+
+	theirIds, err := parseActiveIds(theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	contains := func(ids []uint) map[uint]bool {
+		set := make(map[uint]bool, len(ids))
+		for _, id := range ids {
+			set[id] = true
+		}
+		return set
+	}
+
+	inBase, inOurs, inTheirs := contains(baseIds), contains(ourIds), contains(theirIds)
+
+	isRemoved := func(id uint) bool {
+		return inBase[id] && (!inOurs[id] || !inTheirs[id])
+	}
+
+	merged := bytes.NewBuffer(make([]byte, 0, len(ours)+len(theirs)))
+	for _, id := range ourIds {
+		if !isRemoved(id) {
+			fmt.Fprintln(merged, id)
+		}
+	}
+
+	for _, id := range theirIds {
+		if !inOurs[id] && !isRemoved(id) {
+			fmt.Fprintln(merged, id)
+		}
+	}
+
+	return merged.Bytes(), nil
+}
+
+// Merges the next available id from both sides of
+// a merge by keeping the greater id of either side.
+func MergeNextId(base, ours, theirs []byte) ([]byte, error) {
+	var next uint64
+
+	for _, data := range [][]byte{ours, theirs} {
+		id, err := strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next id: %q", data)
+		}
+
+		if id > next {
+			next = id
+		}
+	}
+
+	return []byte(fmt.Sprintf("%d\n", next)), nil
+}
@@ -0,0 +1,46 @@
+package idea
+
+import (
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeIndexMerge(c gospec.Context) {
+	c.Specify("the active index is merged", func() {
+		merge := func(base, ours, theirs string) string {
+			merged, err := MergeActiveIndex([]byte(base), []byte(ours), []byte(theirs))
+			c.Assume(err, IsNil)
+			return string(merged)
+		}
+
+		c.Specify("by keeping the ids activated on both sides", func() {
+			c.Expect(merge("1\n", "1\n2\n", "1\n3\n"), Equals, "1\n2\n3\n")
+		})
+
+		c.Specify("in the order of our side", func() {
+			c.Expect(merge("1\n2\n", "2\n1\n", "1\n2\n4\n"), Equals, "2\n1\n4\n")
+		})
+
+		c.Specify("by removing the ids removed on either side", func() {
+			c.Expect(merge("1\n2\n3\n", "1\n3\n", "1\n2\n3\n5\n"), Equals, "1\n3\n5\n")
+			c.Expect(merge("1\n2\n3\n", "1\n2\n3\n", "2\n3\n"), Equals, "2\n3\n")
+		})
+
+		c.Specify("when it was created on both sides", func() {
+			c.Expect(merge("", "1\n", "2\n"), Equals, "1\n2\n")
+		})
+	})
+
+	c.Specify("the next id is merged by keeping the greater id", func() {
+		merged, err := MergeNextId([]byte("2\n"), []byte("3\n"), []byte("5\n"))
+		c.Assume(err, IsNil)
+		c.Expect(string(merged), Equals, "5\n")
+
+		merged, err = MergeNextId([]byte("2\n"), []byte("4\n"), []byte("2\n"))
+		c.Assume(err, IsNil)
+		c.Expect(string(merged), Equals, "4\n")
+
+		_, err = MergeNextId(nil, []byte("<<<<<<<\n"), []byte("2\n"))
+		c.Expect(err, Not(IsNil))
+	})
+}
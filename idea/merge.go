@@ -0,0 +1,115 @@
+package idea
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ghthor/journal/lock"
+)
+
+// Returned when the changes made to an idea since it was loaded
+// conflict with the changes made to the idea's file.
+// Merged contains the idea with conflict markers in its body.
+type ConflictError struct {
+	Id     uint
+	Merged Idea
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("idea %d was modified since it was loaded and the changes conflict", e.Id)
+}
+
+func IsConflictError(err error) bool {
+	_, ok := err.(ConflictError)
+	return ok
+}
+
+// Merges the changes made to an idea since it was loaded from the store
+// with the changes made to the idea's file since then. The merged idea is
+// treated as if it was loaded from the file so it can be saved without
+// merging again. If the changes conflict this method will return a ConflictError.
+func (d DirectoryStore) MergeIdea(ctx context.Context, idea Idea) (Idea, error) {
+	base, isLoaded := d.loaded[idea.Id]
+	if idea.Id == 0 || !isLoaded {
+		return idea, nil
+	}
+
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return Idea{}, err
+	}
+	defer l.Release()
+
+	// Records the version on disk as loaded
+	ideaOnDisk, err := d.IdeaById(idea.Id)
+	if err != nil {
+		return Idea{}, err
+	}
+
+	switch {
+	case base == ideaOnDisk:
+		return idea, nil
+
+	case idea == base:
+		// Only the file was changed
+		return ideaOnDisk, nil
+	}
+
+	merged, err := mergeIdeas(idea, base, ideaOnDisk)
+	if err != nil {
+		d.loaded[idea.Id] = base
+		return Idea{}, err
+	}
+
+	return merged, nil
+}
+
+// Three-way merge of an idea. The status and name are taken
+// from the side that changed them and the body is merged by line.
+func mergeIdeas(ours, base, theirs Idea) (Idea, error) {
+	merged := ours
+	isConflicted := false
+
+	mergeField := func(ours, base, theirs string) string {
+		switch {
+		case ours == theirs, theirs == base:
+			return ours
+		case ours == base:
+			return theirs
+		}
+
+		isConflicted = true
+		return ours
+	}
+
+	merged.Status = mergeField(ours.Status, base.Status, theirs.Status)
+	merged.Name = mergeField(ours.Name, base.Name, theirs.Name)
+
+	// Record the header conflicts in the body
+	header := ""
+	if isConflicted {
+		header = fmt.Sprintf("<<<<<<< entry\nstatus: %s\nname: %s\n=======\nstatus: %s\nname: %s\n>>>>>>> idea/%d\n",
+			ours.Status, ours.Name, theirs.Status, theirs.Name, ours.Id)
+	}
+
+	body, hasConflicts := mergeBodies(ours, base, theirs)
+	merged.Body = header + body
+
+	if isConflicted || hasConflicts {
+		return Idea{}, ConflictError{ours.Id, merged}
+	}
+
+	return merged, nil
+}
+
+func mergeBodies(ours, base, theirs Idea) (string, bool) {
+	if ours.Body == theirs.Body || theirs.Body == base.Body {
+		return ours.Body, false
+	}
+
+	if ours.Body == base.Body {
+		return theirs.Body, false
+	}
+
+	return mergeLines(ours.Body, base.Body, theirs.Body, "entry", fmt.Sprintf("idea/%d", ours.Id))
+}
@@ -0,0 +1,333 @@
+package idea
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/lock"
+)
+
+// Returned by RenumberDuplicates if the directory store
+// doesn't contain any ideas that were created with the same
+// id on both sides of a merge.
+var ErrNoDuplicateIds = errors.New("no duplicate idea ids to renumber")
+
+// Describes the changes made by RenumberDuplicates.
+// Renumbered maps the id the idea was created with on the
+// merged in side of the merge to the id it was renumbered to.
+type Renumbering struct {
+	git.Commitable
+	Renumbered map[uint]uint
+}
+
+// Resolves the conflicts created by merging two clones of a journal
+// that each created a new idea using the same id.
+// The idea created on our side of the merge keeps its id.
+// The idea from the merged in side of the merge is assigned
+// the next available id from the union of both sides.
+// The active index and the next available id are rewritten
+// to include the ideas from both sides of the merge.
+//
+// The returned changes resolve the conflicts once they are `git add`ed.
+// If there are no duplicate ids this method will return ErrNoDuplicateIds.
+func (d DirectoryStore) RenumberDuplicates(ctx context.Context) (*Renumbering, error) {
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	unmerged, err := unmergedIn(ctx, d.root)
+	if err != nil {
+		return nil, err
+	}
+
+	// An idea that was added on both sides of the merge
+	// won't have a common ancestor in stage 1
+	duplicates := make([]uint, 0, len(unmerged))
+	for path, stages := range unmerged {
+		id, err := strconv.ParseUint(path, 10, 0)
+		if err != nil {
+			continue
+		}
+
+		if !stages[1] && stages[2] && stages[3] {
+			duplicates = append(duplicates, uint(id))
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return nil, ErrNoDuplicateIds
+	}
+	sort.Sort(idsAscending(duplicates))
+
+	// Both sides of the merge may have allocated ids
+	// that didn't conflict so the next available id
+	// must be greater than either side's.
+	oursNextId, err := d.nextIdFrom(ctx, unmerged, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	theirsNextId, err := d.nextIdFrom(ctx, unmerged, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	nextId := oursNextId
+	if theirsNextId > nextId {
+		nextId = theirsNextId
+	}
+
+	activeIds, err := d.mergedActiveIds(ctx, unmerged)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := git.NewChangesIn(d.root)
+	renumbered := make(map[uint]uint, len(duplicates))
+
+	for _, id := range duplicates {
+		ours, err := ideaFromStage(ctx, d.root, fmt.Sprint(id), 2)
+		if err != nil {
+			return nil, err
+		}
+
+		theirs, err := ideaFromStage(ctx, d.root, fmt.Sprint(id), 3)
+		if err != nil {
+			return nil, err
+		}
+
+		theirs.Id = nextId
+		nextId++
+		renumbered[id] = theirs.Id
+
+		for _, idea := range []Idea{ours, theirs} {
+			err := writeIdeaTo(d.root, idea)
+			if err != nil {
+				return nil, err
+			}
+			changes.Add(git.ChangedFile(fmt.Sprint(idea.Id)))
+
+			activeIds = withActiveId(activeIds, idea.Id, d.statuses.CarriesOver(idea.Status))
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(d.root, "nextid"), []byte(fmt.Sprintf("%d\n", nextId)), 0600)
+	if err != nil {
+		return nil, err
+	}
+	changes.Add(git.ChangedFile("nextid"))
+
+	err = writeActiveIdsTo(d.root, activeIds)
+	if err != nil {
+		return nil, err
+	}
+	changes.Add(git.ChangedFile("active"))
+
+	renumberings := make([]string, 0, len(duplicates))
+	for _, id := range duplicates {
+		renumberings = append(renumberings, fmt.Sprintf("%d -> %d", id, renumbered[id]))
+	}
+	changes.Msg = fmt.Sprintf("idea - renumbered - %s", strings.Join(renumberings, ", "))
+
+	return &Renumbering{changes, renumbered}, nil
+}
+
+type idsAscending []uint
+
+func (ids idsAscending) Len() int           { return len(ids) }
+func (ids idsAscending) Less(i, j int) bool { return ids[i] < ids[j] }
+func (ids idsAscending) Swap(i, j int)      { ids[i], ids[j] = ids[j], ids[i] }
+
+// Returns a map of unmerged paths to the stages
+// that exist in the index for each path.
+func unmergedIn(ctx context.Context, directory string) (map[string]map[int]bool, error) {
+	o, err := git.Exec(ctx, directory, "ls-files", "-u", "-z", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("error listing unmerged files: %v", err)
+	}
+
+	unmerged := make(map[string]map[int]bool)
+
+	for _, record := range bytes.Split(o, []byte{0}) {
+		if len(record) == 0 {
+			continue
+		}
+
+		// <mode> <object> <stage>\t<path>
+		var (
+			mode, object string
+			stage        int
+		)
+
+		i := bytes.IndexByte(record, '\t')
+		if i < 0 {
+			return nil, fmt.Errorf("unexpected `git ls-files` output: %q", record)
+		}
+
+		_, err := fmt.Sscan(string(record[:i]), &mode, &object, &stage)
+		if err != nil {
+			return nil, err
+		}
+
+		path := string(record[i+1:])
+		if unmerged[path] == nil {
+			unmerged[path] = make(map[int]bool, 3)
+		}
+		unmerged[path][stage] = true
+	}
+
+	return unmerged, nil
+}
+
+// Read a file from the directory store. If the file is unmerged
+// the version from the stage is returned.
+func (d DirectoryStore) readFromStage(ctx context.Context, unmerged map[string]map[int]bool, filename string, stage int) ([]byte, error) {
+	if unmerged[filename] == nil {
+		return ioutil.ReadFile(filepath.Join(d.root, filename))
+	}
+
+	return showStage(ctx, d.root, filename, stage)
+}
+
+func showStage(ctx context.Context, directory, filename string, stage int) ([]byte, error) {
+	o, err := git.Exec(ctx, directory, "show", fmt.Sprintf(":%d:./%s", stage, filename))
+	if err != nil {
+		return nil, fmt.Errorf("error reading stage %d of %s: %v", stage, filename, err)
+	}
+	return o, nil
+}
+
+func (d DirectoryStore) nextIdFrom(ctx context.Context, unmerged map[string]map[int]bool, stage int) (uint, error) {
+	data, err := d.readFromStage(ctx, unmerged, "nextid", stage)
+	if err != nil {
+		return 0, err
+	}
+
+	var nextId uint
+	_, err = fmt.Fscan(bytes.NewReader(data), &nextId)
+	if err != nil {
+		return 0, err
+	}
+
+	return nextId, nil
+}
+
+// Returns the union of the active ids from both sides of the merge
+func (d DirectoryStore) mergedActiveIds(ctx context.Context, unmerged map[string]map[int]bool) ([]uint, error) {
+	activeIds := make([]uint, 0, 8)
+
+	for _, stage := range []int{2, 3} {
+		data, err := d.readFromStage(ctx, unmerged, "active", stage)
+		if err != nil {
+			return nil, err
+		}
+
+		ids, err := scanIds(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			activeIds = withActiveId(activeIds, id, true)
+		}
+	}
+
+	return activeIds, nil
+}
+
+func scanIds(r io.Reader) ([]uint, error) {
+	ids := make([]uint, 0, 8)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// Ignore conflict markers left behind by `git merge`
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 || strings.IndexAny(scanner.Text()[:1], "<=>") == 0 {
+			continue
+		}
+
+		var id uint
+		_, err := fmt.Fscan(bytes.NewReader(scanner.Bytes()), &id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, scanner.Err()
+}
+
+// Add or remove the id from the slice of active ids
+func withActiveId(activeIds []uint, id uint, isActive bool) []uint {
+	for i, activeId := range activeIds {
+		if activeId == id {
+			if isActive {
+				return activeIds
+			}
+			return append(activeIds[:i], activeIds[i+1:]...)
+		}
+	}
+
+	if isActive {
+		return append(activeIds, id)
+	}
+	return activeIds
+}
+
+func ideaFromStage(ctx context.Context, directory, filename string, stage int) (Idea, error) {
+	data, err := showStage(ctx, directory, filename, stage)
+	if err != nil {
+		return Idea{}, err
+	}
+
+	scanner := NewIdeaScanner(bytes.NewReader(data))
+	if !scanner.Scan() {
+		if scanner.Err() != nil {
+			return Idea{}, scanner.Err()
+		}
+		return Idea{}, fmt.Errorf("stage %d of %s doesn't contain an idea", stage, filename)
+	}
+
+	return *scanner.Idea(), nil
+}
+
+func writeIdeaTo(directory string, idea Idea) error {
+	r, err := NewIdeaReader(idea)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(directory, fmt.Sprint(idea.Id)), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func writeActiveIdsTo(directory string, activeIds []uint) error {
+	buf := bytes.NewBuffer(make([]byte, 0, 256))
+	for _, id := range activeIds {
+		_, err := fmt.Fprintln(buf, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(filepath.Join(directory, "active"), buf.Bytes(), 0600)
+}
@@ -0,0 +1,169 @@
+package idea
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// The layout of the dates used in an idea's body
+const DateLayout = "2006-01-02"
+
+const (
+	// Prefix of the line in an idea's body that sets the idea's due date
+	DuePrefix = "Due:"
+
+	// Prefix of the line in an idea's body that hides
+	// the idea from new entries until the date
+	DeferUntilPrefix = "Defer-Until:"
+
+	// Prefix of the token in a task that sets the task's due date
+	TaskDuePrefix = "due:"
+)
+
+// A checklist item in an idea's body, written as
+//   - [ ] the task due:2015-01-02
+//   - [x] a completed task
+type Task struct {
+	Text string
+	Done bool
+
+	// Zero if the task doesn't have a due date
+	Due time.Time
+}
+
+// The dates parsed from an idea's body.
+// Dates that aren't set are the zero time.
+type Schedule struct {
+	Due        time.Time
+	DeferUntil time.Time
+
+	Tasks []Task
+}
+
+// Returned if a date in an idea's body can't be parsed
+type InvalidDateError struct {
+	Line  string
+	Value string
+}
+
+func (e InvalidDateError) Error() string {
+	return fmt.Sprintf("invalid date %q, dates must be written as %s: %s", e.Value, DateLayout, e.Line)
+}
+
+func IsInvalidDateError(err error) bool {
+	_, ok := err.(InvalidDateError)
+	return ok
+}
+
+// Matches a value that is written like a date in the DateLayout
+var dateLike = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}$`)
+
+// Parses the date written in a schedule line. A value that isn't written
+// like a date is prose and isn't part of the schedule, so ok is false.
+// A value that is written like a date but isn't one is an InvalidDateError.
+func parseDate(line, value string) (t time.Time, ok bool, err error) {
+	if !dateLike.MatchString(value) {
+		return time.Time{}, false, nil
+	}
+
+	t, err = time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, false, InvalidDateError{line, value}
+	}
+	return t, true, nil
+}
+
+// Parses the due date, defer until date and the tasks from the idea's body
+func (i Idea) Schedule() (s Schedule, err error) {
+	scanner := bufio.NewScanner(strings.NewReader(i.Body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, DuePrefix):
+			due, ok, err := parseDate(line, strings.TrimSpace(strings.TrimPrefix(line, DuePrefix)))
+			if err != nil {
+				return Schedule{}, err
+			}
+			if ok {
+				s.Due = due
+			}
+
+		case strings.HasPrefix(line, DeferUntilPrefix):
+			deferUntil, ok, err := parseDate(line, strings.TrimSpace(strings.TrimPrefix(line, DeferUntilPrefix)))
+			if err != nil {
+				return Schedule{}, err
+			}
+			if ok {
+				s.DeferUntil = deferUntil
+			}
+
+		case isTaskLine(line):
+			var task Task
+			task, err = parseTask(line)
+			s.Tasks = append(s.Tasks, task)
+		}
+
+		if err != nil {
+			return Schedule{}, err
+		}
+	}
+
+	return s, scanner.Err()
+}
+
+func isTaskLine(line string) bool {
+	return strings.HasPrefix(line, "- [ ] ") || strings.HasPrefix(line, "- [x] ")
+}
+
+func parseTask(line string) (Task, error) {
+	task := Task{Done: line[3] == 'x'}
+
+	words := strings.Fields(line[len("- [ ] "):])
+	text := make([]string, 0, len(words))
+
+	for _, word := range words {
+		if strings.HasPrefix(word, TaskDuePrefix) {
+			due, ok, err := parseDate(line, strings.TrimPrefix(word, TaskDuePrefix))
+			if err != nil {
+				return Task{}, err
+			}
+
+			if ok {
+				task.Due = due
+				continue
+			}
+		}
+
+		text = append(text, word)
+	}
+
+	task.Text = strings.Join(text, " ")
+	return task, nil
+}
+
+// Returns the date of the time as midnight UTC
+// so it can be compared with the dates in an idea
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Returns true if the idea should be hidden from an entry opened at now
+func (s Schedule) IsDeferred(now time.Time) bool {
+	return !s.DeferUntil.IsZero() && s.DeferUntil.After(dateOf(now))
+}
+
+// Returns true if the idea's due date is before the day of now
+func (s Schedule) IsOverdue(now time.Time) bool {
+	return !s.Due.IsZero() && s.Due.Before(dateOf(now))
+}
+
+// Returns true if the date is before the end of the
+// day that is days after the day of now.
+// Dates that are overdue are also due within any number of days.
+func IsDueWithin(date, now time.Time, days int) bool {
+	return !date.IsZero() && date.Before(dateOf(now).AddDate(0, 0, days+1))
+}
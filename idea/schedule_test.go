@@ -0,0 +1,86 @@
+package idea
+
+import (
+	"time"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeSchedule(c gospec.Context) {
+	c.Specify("an idea's schedule", func() {
+		date := func(year int, month time.Month, day int) time.Time {
+			return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		}
+
+		idea := Idea{
+			Status: IS_Active,
+			Id:     1,
+			Name:   "a scheduled idea",
+			Body: `Due: 2015-01-10
+Defer-Until: 2015-01-05
+
+- [ ] a task due:2015-01-07
+- [x] a completed task
+  - [ ] a nested task
+`,
+		}
+
+		schedule, err := idea.Schedule()
+		c.Assume(err, IsNil)
+
+		c.Specify("is parsed from the idea's body", func() {
+			c.Expect(schedule.Due, Equals, date(2015, time.January, 10))
+			c.Expect(schedule.DeferUntil, Equals, date(2015, time.January, 5))
+
+			c.Expect(len(schedule.Tasks), Equals, 3)
+			c.Expect(schedule.Tasks[0], Equals, Task{"a task", false, date(2015, time.January, 7)})
+			c.Expect(schedule.Tasks[1], Equals, Task{"a completed task", true, time.Time{}})
+			c.Expect(schedule.Tasks[2], Equals, Task{"a nested task", false, time.Time{}})
+		})
+
+		c.Specify("is empty for an idea without any dates", func() {
+			schedule, err := Idea{Status: IS_Active, Name: "idea", Body: "body\n"}.Schedule()
+			c.Assume(err, IsNil)
+			c.Expect(schedule.Due.IsZero(), IsTrue)
+			c.Expect(schedule.DeferUntil.IsZero(), IsTrue)
+			c.Expect(len(schedule.Tasks), Equals, 0)
+		})
+
+		c.Specify("will defer the idea until the date", func() {
+			c.Expect(schedule.IsDeferred(time.Date(2015, time.January, 4, 23, 59, 0, 0, time.UTC)), IsTrue)
+			c.Expect(schedule.IsDeferred(time.Date(2015, time.January, 5, 0, 1, 0, 0, time.UTC)), IsFalse)
+		})
+
+		c.Specify("will be overdue after the due date", func() {
+			c.Expect(schedule.IsOverdue(time.Date(2015, time.January, 10, 23, 59, 0, 0, time.UTC)), IsFalse)
+			c.Expect(schedule.IsOverdue(time.Date(2015, time.January, 11, 0, 1, 0, 0, time.UTC)), IsTrue)
+		})
+
+		c.Specify("can be due within a number of days", func() {
+			now := time.Date(2015, time.January, 3, 12, 0, 0, 0, time.UTC)
+			c.Expect(IsDueWithin(schedule.Due, now, 7), IsTrue)
+			c.Expect(IsDueWithin(schedule.Due, now, 6), IsFalse)
+			c.Expect(IsDueWithin(date(2015, time.January, 1), now, 0), IsTrue)
+			c.Expect(IsDueWithin(time.Time{}, now, 7), IsFalse)
+		})
+
+		c.Specify("will ignore prose that isn't a date", func() {
+			s, err := Idea{Status: IS_Active, Name: "idea", Body: "Due: after the review\nDefer-Until: tomorrow\n- [ ] ask about due:dates\n"}.Schedule()
+			c.Assume(err, IsNil)
+			c.Expect(s.Due.IsZero(), IsTrue)
+			c.Expect(s.DeferUntil.IsZero(), IsTrue)
+			c.Assume(len(s.Tasks), Equals, 1)
+			c.Expect(s.Tasks[0].Text, Equals, "ask about due:dates")
+			c.Expect(s.Tasks[0].Due.IsZero(), IsTrue)
+		})
+
+		c.Specify("will fail to parse an invalid date", func() {
+			_, err := Idea{Status: IS_Active, Name: "idea", Body: "Due: 2015-02-30\n"}.Schedule()
+			c.Expect(err, Equals, InvalidDateError{"Due: 2015-02-30", "2015-02-30"})
+
+			_, err = Idea{Status: IS_Active, Name: "idea", Body: "- [ ] task due:2015-13-01\n"}.Schedule()
+			c.Expect(IsInvalidDateError(err), IsTrue)
+		})
+	})
+}
@@ -0,0 +1,226 @@
+package idea
+
+import (
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The similarity above which an idea is considered a likely duplicate
+const DefaultSimilarity = 0.75
+
+// An existing idea and how similar its name is to another name
+type SimilarIdea struct {
+	Idea
+
+	// Between 0 and 1, 1 being the same name
+	Similarity float64
+}
+
+// Lowercases the name and reduces it to its words
+func normalizeName(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// The edit distance between a and b, counting
+// a transposition of two adjacent runes as one edit
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = d[i-1][j-1] + cost
+			if d[i-1][j]+1 < d[i][j] {
+				d[i][j] = d[i-1][j] + 1
+			}
+			if d[i][j-1]+1 < d[i][j] {
+				d[i][j] = d[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+
+	return d[len(a)][len(b)]
+}
+
+// Words shorter than this must be spelled the same to match
+const minTypoLength = 3
+
+// Returns how much two words match, between 0 and 1. Words that differ
+// by more than a single typo, like "active" and "inactive", don't match.
+func wordSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	aRunes, bRunes := []rune(a), []rune(b)
+	if len(aRunes) < minTypoLength || len(bRunes) < minTypoLength || editDistance(aRunes, bRunes) > 1 {
+		return 0
+	}
+
+	longest := len(aRunes)
+	if len(bRunes) > longest {
+		longest = len(bRunes)
+	}
+
+	return 1 - 1/float64(longest)
+}
+
+// Returns the words with the duplicates removed, in the order they first appear
+func uniqueWords(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	unique := make([]string, 0, len(words))
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			unique = append(unique, word)
+		}
+	}
+	return unique
+}
+
+// Returns how similar two idea names are, between 0 and 1. The names
+// are compared ignoring case and punctuation by the words they share.
+// A word that is misspelled by a single typo is partially shared.
+// Names that don't share any words aren't similar.
+func NameSimilarity(a, b string) float64 {
+	aWords, bWords := uniqueWords(normalizeName(a)), uniqueWords(normalizeName(b))
+	if len(aWords) == 0 || len(bWords) == 0 {
+		return 0
+	}
+
+	// Words spelled the same are matched before the misspelled words
+	matched := make([]bool, len(bWords))
+	var unmatched []string
+	shared := 0.0
+
+	for _, aWord := range aWords {
+		found := false
+		for j, bWord := range bWords {
+			if !matched[j] && aWord == bWord {
+				matched[j], found = true, true
+				shared++
+				break
+			}
+		}
+
+		if !found {
+			unmatched = append(unmatched, aWord)
+		}
+	}
+
+	for _, aWord := range unmatched {
+		best, bestJ := 0.0, -1
+		for j, bWord := range bWords {
+			if similarity := wordSimilarity(aWord, bWord); !matched[j] && similarity > best {
+				best, bestJ = similarity, j
+			}
+		}
+
+		if bestJ >= 0 {
+			matched[bestJ] = true
+			shared += best
+		}
+	}
+
+	// Dice coefficient of the words
+	return 2 * shared / float64(len(aWords)+len(bWords))
+}
+
+type bySimilarity []SimilarIdea
+
+func (s bySimilarity) Len() int      { return len(s) }
+func (s bySimilarity) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s bySimilarity) Less(i, j int) bool {
+	if s[i].Similarity == s[j].Similarity {
+		return s[i].Id < s[j].Id
+	}
+	return s[i].Similarity > s[j].Similarity
+}
+
+// Calls fn with each idea in the store, including the ideas that
+// aren't active, in the order of their ids. Ideas that have been
+// merged are skipped. Stops when fn returns false.
+func (d DirectoryStore) eachUnmerged(fn func(Idea) bool) error {
+	files, err := ioutil.ReadDir(d.root)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int, 0, len(files))
+	for _, fi := range files {
+		id, err := strconv.ParseUint(fi.Name(), 10, 0)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		idea, err := readIdeaFrom(d.root, uint(id))
+		if err != nil {
+			return err
+		}
+
+		if idea.Status == IS_Merged {
+			continue
+		}
+
+		if !fn(idea) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Returns the ideas in the store, including the ideas that aren't active,
+// with a name that is at least as similar to name as the threshold.
+// The most similar idea is first. Ideas that have been merged are skipped.
+func (d DirectoryStore) SimilarIdeas(name string, threshold float64) ([]SimilarIdea, error) {
+	similar := make([]SimilarIdea, 0, 4)
+	err := d.eachUnmerged(func(idea Idea) bool {
+		if similarity := NameSimilarity(name, idea.Name); similarity >= threshold {
+			similar = append(similar, SimilarIdea{idea, similarity})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(bySimilarity(similar))
+
+	return similar, nil
+}
+
+// Returns the idea with the lowest id that is named exactly name, including
+// the ideas that aren't active. Ideas that have been merged are skipped.
+func (d DirectoryStore) IdeaNamed(name string) (idea Idea, found bool, err error) {
+	err = d.eachUnmerged(func(i Idea) bool {
+		if i.Name == name {
+			idea, found = i, true
+		}
+		return !found
+	})
+	return idea, found, err
+}
@@ -0,0 +1,33 @@
+package idea
+
+import (
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+)
+
+func DescribeNameSimilarity(c gospec.Context) {
+	c.Specify("the similarity of two idea names", func() {
+		c.Specify("ignores case and punctuation", func() {
+			c.Expect(NameSimilarity("Write the report", "write the report!"), Equals, 1.0)
+			c.Expect(NameSimilarity("journal-idea", "Journal Idea"), Equals, 1.0)
+		})
+
+		c.Specify("is high for a reworded name", func() {
+			c.Expect(NameSimilarity("Write the report", "Write report") >= DefaultSimilarity, IsTrue)
+			c.Expect(NameSimilarity("report for the quarter", "the quarter report") >= DefaultSimilarity, IsTrue)
+			c.Expect(NameSimilarity("Fix the parser", "Fix teh parser") >= DefaultSimilarity, IsTrue)
+		})
+
+		c.Specify("is low for a different name", func() {
+			c.Expect(NameSimilarity("Write the report", "Plan a vacation") < DefaultSimilarity, IsTrue)
+			c.Expect(NameSimilarity("Write the report", "") < DefaultSimilarity, IsTrue)
+			c.Expect(NameSimilarity("Active Idea", "Inactive Idea") < DefaultSimilarity, IsTrue)
+			c.Expect(NameSimilarity("Write the report", "Write the summary") < DefaultSimilarity, IsTrue)
+		})
+
+		c.Specify("is 0 for names that don't share a word", func() {
+			c.Expect(NameSimilarity("Read a book", "Write the report"), Equals, 0.0)
+			c.Expect(NameSimilarity("!!", "!!"), Equals, 0.0)
+		})
+	})
+}
@@ -10,6 +10,11 @@ func TestUnitSpecs(t *testing.T) {
 
 	r.AddSpec(DescribeIdea)
 	r.AddSpec(DescribeIdeaStore)
+	r.AddSpec(DescribeSchedule)
+	r.AddSpec(DescribeIdeaFormat)
+	r.AddSpec(DescribeNameSimilarity)
+	r.AddSpec(DescribeIndexMerge)
+	r.AddSpec(DescribeMergeLines)
 
 	gospec.MainGoTest(r, t)
 }
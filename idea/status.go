@@ -0,0 +1,163 @@
+package idea
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Defines a valid Idea.Status value
+type Status struct {
+	Name string `json:"name"`
+
+	// Ideas with this status are stored in the active index
+	// and are carried into every new entry.
+	CarryOver bool `json:"carryOver"`
+
+	// The statuses an idea with this status can be changed to.
+	// If empty an idea can be changed to any status.
+	Transitions []string `json:"transitions,omitempty"`
+}
+
+// The set of statuses an idea can have
+type Statuses []Status
+
+// Used by a DirectoryStore unless it's configured with other statuses
+var DefaultStatuses = Statuses{
+	{Name: IS_Active, CarryOver: true},
+	{Name: IS_Inactive},
+	{Name: IS_Completed},
+}
+
+// Returned when saving an idea with a status
+// that isn't defined in the store's statuses
+type UnknownStatusError struct {
+	Status string
+}
+
+func (e UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown idea status: %s", e.Status)
+}
+
+func IsUnknownStatusError(err error) bool {
+	_, ok := err.(UnknownStatusError)
+	return ok
+}
+
+// Returned when updating an idea's status to a status that
+// isn't an allowed transition from the idea's current status
+type InvalidTransitionError struct {
+	Id       uint
+	From, To string
+}
+
+func (e InvalidTransitionError) Error() string {
+	return fmt.Sprintf("idea %d cannot be changed from %s to %s", e.Id, e.From, e.To)
+}
+
+func IsInvalidTransitionError(err error) bool {
+	_, ok := err.(InvalidTransitionError)
+	return ok
+}
+
+// The status of an idea that was merged into another idea.
+// It's defined for every set of statuses and is never carried over
+// unless it is defined with different rules.
+const IS_Merged = "merged"
+
+// Returns the definition of the status with name
+func (s Statuses) Lookup(name string) (Status, bool) {
+	for _, status := range s {
+		if status.Name == name {
+			return status, true
+		}
+	}
+
+	if name == IS_Merged {
+		return Status{Name: IS_Merged}, true
+	}
+
+	return Status{}, false
+}
+
+// Returns true if ideas with the status are carried into new entries
+func (s Statuses) CarriesOver(name string) bool {
+	status, exists := s.Lookup(name)
+	return exists && status.CarryOver
+}
+
+// Returned when an idea can't be brought back into new entries
+// because no status that is carried over is defined
+var ErrNoCarriedOverStatus = errors.New("no idea status is carried over into new entries")
+
+// Returns the status an idea with the status from is changed to when it's
+// brought back into new entries. This is the first status that is carried over
+// and that the idea can be changed to. If from is carried over it's returned.
+func (s Statuses) Reactivated(from string) (string, error) {
+	if s.CarriesOver(from) {
+		return from, nil
+	}
+
+	var err error = ErrNoCarriedOverStatus
+	for _, status := range s {
+		if !status.CarryOver {
+			continue
+		}
+
+		if err = s.CanTransition(from, status.Name); err == nil {
+			return status.Name, nil
+		}
+	}
+
+	return "", err
+}
+
+// Returns an error if an idea cannot be changed from one status to another
+func (s Statuses) CanTransition(from, to string) error {
+	if _, exists := s.Lookup(to); !exists {
+		return UnknownStatusError{to}
+	}
+
+	status, exists := s.Lookup(from)
+	if !exists || from == to || len(status.Transitions) == 0 {
+		return nil
+	}
+
+	for _, name := range status.Transitions {
+		if name == to {
+			return nil
+		}
+	}
+
+	return InvalidTransitionError{From: from, To: to}
+}
+
+// Checks that every status has a name that can be written into
+// an idea header and that every transition is to a defined status
+func (s Statuses) Validate() error {
+	if len(s) == 0 {
+		return errors.New("at least one idea status must be defined")
+	}
+
+	for i, status := range s {
+		if len(status.Name) == 0 || strings.ContainsAny(status.Name, "[] \t\n") {
+			return fmt.Errorf("invalid idea status name: %q", status.Name)
+		}
+
+		for _, defined := range s[:i] {
+			if defined.Name == status.Name {
+				return fmt.Errorf("idea status defined more than once: %s", status.Name)
+			}
+		}
+	}
+
+	for _, status := range s {
+		for _, name := range status.Transitions {
+			if _, exists := s.Lookup(name); !exists {
+				return fmt.Errorf("idea status %s has a transition to an unknown status: %s", status.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package idea
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,11 +12,19 @@ import (
 	"path/filepath"
 
 	"github.com/ghthor/journal/git"
+	"github.com/ghthor/journal/lock"
 )
 
 // Used to manage idea storage in a directory
 type DirectoryStore struct {
 	root string
+
+	statuses Statuses
+
+	// The version of each idea when it was loaded from the store.
+	// Used as the base when merging changes made to an idea
+	// with changes made to the idea's file since it was loaded.
+	loaded map[uint]Idea
 }
 
 // Returned if a directory structure doesn't match
@@ -64,7 +73,20 @@ func NewDirectoryStore(directory string) (*DirectoryStore, error) {
 		return nil, err
 	}
 
-	return &DirectoryStore{directory}, nil
+	return &DirectoryStore{directory, DefaultStatuses, make(map[uint]Idea)}, nil
+}
+
+// Configure the statuses the ideas in the store can have.
+// The active index will contain the ideas that have a
+// status that is carried over into new entries.
+func (d *DirectoryStore) SetStatuses(statuses Statuses) error {
+	err := statuses.Validate()
+	if err != nil {
+		return err
+	}
+
+	d.statuses = statuses
+	return nil
 }
 
 // Returned if InitDirectoryStore is called on a directory
@@ -97,19 +119,25 @@ func InitDirectoryStore(directory string) (*DirectoryStore, git.Commitable, erro
 	changes.Add(git.ChangedFile("active"))
 	changes.Msg = "idea directory store initialized"
 
-	return &DirectoryStore{directory}, changes, nil
+	return &DirectoryStore{directory, DefaultStatuses, make(map[uint]Idea)}, changes, nil
 }
 
 // Saves an idea to the directory store and
 // returns a commitable containing all changes.
 // If the idea does not have an id it will be assigned one.
 // If the idea does have an id it will be updated.
-func (d DirectoryStore) SaveIdea(idea *Idea) (git.Commitable, error) {
+func (d DirectoryStore) SaveIdea(ctx context.Context, idea *Idea) (git.Commitable, error) {
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
 	if idea.Id == 0 {
 		return d.saveNewIdea(idea)
 	}
 
-	return d.UpdateIdea(*idea)
+	return d.UpdateIdea(ctx, *idea)
 }
 
 var ErrIdeaExists = errors.New("cannot save a new idea because it already exists")
@@ -122,11 +150,27 @@ func (d DirectoryStore) SaveNewIdea(idea *Idea) (git.Commitable, error) {
 	if idea.Id != 0 {
 		return nil, ErrIdeaExists
 	}
+
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
 	return d.saveNewIdea(idea)
 }
 
-// Does not check if the idea has an id
+// Does not check if the idea has an id.
+// The caller must hold the journal lock.
 func (d DirectoryStore) saveNewIdea(idea *Idea) (git.Commitable, error) {
+	if _, exists := d.statuses.Lookup(idea.Status); !exists {
+		return nil, UnknownStatusError{idea.Status}
+	}
+
+	if _, err := idea.Schedule(); err != nil {
+		return nil, err
+	}
+
 	changes := git.NewChangesIn(d.root)
 
 	// Retrieve nextid
@@ -170,8 +214,8 @@ func (d DirectoryStore) saveNewIdea(idea *Idea) (git.Commitable, error) {
 	}
 	changes.Add(git.ChangedFile(filepath.Base(ideaFile.Name())))
 
-	// If Active, append to active index
-	if idea.Status == IS_Active {
+	// If carried over, append to active index
+	if d.statuses.CarriesOver(idea.Status) {
 		activeIndexFile, err := os.OpenFile(filepath.Join(d.root, "active"), os.O_APPEND|os.O_WRONLY, 0600)
 		if err != nil {
 			return nil, err
@@ -185,7 +229,9 @@ func (d DirectoryStore) saveNewIdea(idea *Idea) (git.Commitable, error) {
 		changes.Add(git.ChangedFile("active"))
 	}
 
+	d.loaded[idea.Id] = *idea
 	changes.Msg = fmt.Sprintf("idea - created - %d", idea.Id)
+	changes.Description = commitDescriptionOf(git.IdeaCreatedCommit, *idea)
 
 	return changes, nil
 }
@@ -196,8 +242,30 @@ var ErrIdeaNotModified = errors.New("the idea was not modified")
 // exists in the directory already and
 // returns a commitable containing all changes.
 // If the idea body wasn't modified this method will
-// return ErrIdeaNotModified
-func (d DirectoryStore) UpdateIdea(idea Idea) (git.Commitable, error) {
+// return ErrIdeaNotModified.
+// If the idea's status isn't defined or the idea's status
+// cannot be changed to it this method will return an
+// UnknownStatusError or an InvalidTransitionError.
+// If a date in the idea's body is written like a date
+// but isn't one this method will return an InvalidDateError.
+// If the idea's file was changed since the idea was loaded from the store
+// the changes are merged and if they conflict this method
+// will return a ConflictError.
+func (d DirectoryStore) UpdateIdea(ctx context.Context, idea Idea) (git.Commitable, error) {
+	if _, exists := d.statuses.Lookup(idea.Status); !exists {
+		return nil, UnknownStatusError{idea.Status}
+	}
+
+	if _, err := idea.Schedule(); err != nil {
+		return nil, err
+	}
+
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
 	changes := git.NewChangesIn(d.root)
 
 	data, err := ioutil.ReadFile(filepath.Join(d.root, fmt.Sprint(idea.Id)))
@@ -216,11 +284,33 @@ func (d DirectoryStore) UpdateIdea(idea Idea) (git.Commitable, error) {
 		return nil, scanner.Err()
 	}
 
+	// Merge with the changes made to the file since the idea was loaded
+	if base, isLoaded := d.loaded[idea.Id]; isLoaded && base != *ideaOnDisk {
+		if idea == base {
+			// Only the file was changed
+			return nil, ErrIdeaNotModified
+		}
+
+		idea, err = mergeIdeas(idea, base, *ideaOnDisk)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if idea == *ideaOnDisk {
 		// No change
 		return nil, ErrIdeaNotModified
 	}
 
+	err = d.statuses.CanTransition(ideaOnDisk.Status, idea.Status)
+	if err != nil {
+		if e, ok := err.(InvalidTransitionError); ok {
+			e.Id = idea.Id
+			return nil, e
+		}
+		return nil, err
+	}
+
 	// Write to new idea data to file
 	ir, err := NewIdeaReader(idea)
 	if err != nil {
@@ -240,8 +330,11 @@ func (d DirectoryStore) UpdateIdea(idea Idea) (git.Commitable, error) {
 
 	changes.Add(git.ChangedFile(fmt.Sprint(idea.Id)))
 
-	if idea.Status != ideaOnDisk.Status {
-		if idea.Status == IS_Active {
+	wasCarriedOver := d.statuses.CarriesOver(ideaOnDisk.Status)
+	isCarriedOver := d.statuses.CarriesOver(idea.Status)
+
+	if wasCarriedOver != isCarriedOver {
+		if isCarriedOver {
 			// add the id to the active index
 			activeIndex, err := os.OpenFile(filepath.Join(d.root, "active"), os.O_APPEND|os.O_WRONLY, 0600)
 			if err != nil {
@@ -255,7 +348,7 @@ func (d DirectoryStore) UpdateIdea(idea Idea) (git.Commitable, error) {
 			}
 			changes.Add(git.ChangedFile("active"))
 
-		} else if ideaOnDisk.Status == IS_Active {
+		} else {
 			// remove the id from the active index
 			activeIndex_RDONLY, err := os.OpenFile(filepath.Join(d.root, "active"), os.O_RDONLY, 0600)
 			if err != nil {
@@ -298,11 +391,25 @@ func (d DirectoryStore) UpdateIdea(idea Idea) (git.Commitable, error) {
 		}
 	}
 
+	d.loaded[idea.Id] = idea
 	changes.Msg = fmt.Sprintf("idea - updated - %d", idea.Id)
+	changes.Description = commitDescriptionOf(git.IdeaUpdatedCommit, idea)
 
 	return changes, nil
 }
 
+// Describes the commit that saves the idea to a commit message template
+func commitDescriptionOf(kind string, idea Idea) git.Description {
+	return git.Description{
+		Kind: kind,
+		Idea: git.IdeaDescription{
+			Id:     idea.Id,
+			Name:   idea.Name,
+			Status: idea.Status,
+		},
+	}
+}
+
 func activeIdeasIn(directory string) (activeIds []uint, err error) {
 	// Scan in the id's from the index file
 	data, err := ioutil.ReadFile(filepath.Join(directory, "active"))
@@ -310,6 +417,11 @@ func activeIdeasIn(directory string) (activeIds []uint, err error) {
 		return nil, err
 	}
 
+	return parseActiveIds(data)
+}
+
+// Parse the ids in an active index, one id per line
+func parseActiveIds(data []byte) (activeIds []uint, err error) {
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 	activeIds = make([]uint, 0, 3)
 
@@ -344,7 +456,9 @@ func readIdeaFrom(directory string, id uint) (idea Idea, err error) {
 	return idea, nil
 }
 
-// Returns a slice of the active ideas from the store
+// Returns a slice of the ideas in the active index.
+// These are the ideas with a status that is carried into new entries.
+// The store remembers the version of each idea that was returned.
 func (d DirectoryStore) ActiveIdeas() (ideas []Idea, err error) {
 	activeIds, err := activeIdeasIn(d.root)
 	if err != nil {
@@ -357,6 +471,7 @@ func (d DirectoryStore) ActiveIdeas() (ideas []Idea, err error) {
 		if err != nil {
 			return nil, err
 		}
+		d.loaded[id] = idea
 
 		ideas = append(ideas, idea)
 	}
@@ -364,7 +479,149 @@ func (d DirectoryStore) ActiveIdeas() (ideas []Idea, err error) {
 	return ideas, nil
 }
 
-// Returns the Idea object stored by the id
+// Returned by ReorderActive and MoveActive if the
+// order of the active index wasn't changed
+var ErrActiveIndexNotModified = errors.New("the active index was not modified")
+
+// Returned when moving an idea within the active
+// index that isn't in the active index
+type IdeaNotActiveError struct {
+	Id uint
+}
+
+func (e IdeaNotActiveError) Error() string {
+	return fmt.Sprintf("idea %d is not in the active index", e.Id)
+}
+
+func IsIdeaNotActiveError(err error) bool {
+	_, ok := err.(IdeaNotActiveError)
+	return ok
+}
+
+// Rewrites the active index so the ideas are in the order of ids and
+// returns a commitable containing the changes.
+// Ids that aren't in the active index are ignored and ideas that
+// are active but aren't in ids are kept after them in their current order.
+// If the order wasn't changed this method will return ErrActiveIndexNotModified.
+func (d DirectoryStore) ReorderActive(ids []uint) (git.Commitable, error) {
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	activeIds, err := activeIdeasIn(d.root)
+	if err != nil {
+		return nil, err
+	}
+
+	isActive := make(map[uint]bool, len(activeIds))
+	for _, id := range activeIds {
+		isActive[id] = true
+	}
+
+	reordered := make([]uint, 0, len(activeIds))
+	for _, id := range ids {
+		if isActive[id] {
+			reordered = append(reordered, id)
+			isActive[id] = false
+		}
+	}
+
+	for _, id := range activeIds {
+		if isActive[id] {
+			reordered = append(reordered, id)
+		}
+	}
+
+	return d.writeActiveIndex(activeIds, reordered, "idea - reordered active")
+}
+
+// Moves the idea in the active index so it is immediately before
+// another idea and returns a commitable containing the changes.
+// If either idea isn't in the active index this method
+// will return an IdeaNotActiveError.
+func (d DirectoryStore) MoveActive(id, before uint) (git.Commitable, error) {
+	l, err := lock.Journal(d.root)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	activeIds, err := activeIdeasIn(d.root)
+	if err != nil {
+		return nil, err
+	}
+
+	moved := make([]uint, 0, len(activeIds))
+	for _, activeId := range activeIds {
+		if activeId != id {
+			moved = append(moved, activeId)
+		}
+	}
+
+	if len(moved) == len(activeIds) {
+		return nil, IdeaNotActiveError{id}
+	}
+
+	i := 0
+	for ; i < len(moved) && moved[i] != before; i++ {
+	}
+
+	if i == len(moved) {
+		if id == before {
+			return nil, ErrActiveIndexNotModified
+		}
+		return nil, IdeaNotActiveError{before}
+	}
+
+	moved = append(moved[:i], append([]uint{id}, moved[i:]...)...)
+
+	return d.writeActiveIndex(activeIds, moved, fmt.Sprintf("idea - moved - %d before %d", id, before))
+}
+
+// The caller must hold the journal lock
+func (d DirectoryStore) writeActiveIndex(activeIds, newActiveIds []uint, msg string) (git.Commitable, error) {
+	isModified := false
+	for i := range activeIds {
+		if activeIds[i] != newActiveIds[i] {
+			isModified = true
+			break
+		}
+	}
+
+	if !isModified {
+		return nil, ErrActiveIndexNotModified
+	}
+
+	err := writeActiveIdsTo(d.root, newActiveIds)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := git.NewChangesIn(d.root)
+	changes.Add(git.ChangedFile("active"))
+	changes.Msg = msg
+
+	return changes, nil
+}
+
+// Returned when an idea's file doesn't contain an idea
+type NoIdeaError struct {
+	Id uint
+}
+
+func (e NoIdeaError) Error() string {
+	return fmt.Sprintf("the file of idea %d doesn't contain an idea", e.Id)
+}
+
+func IsNoIdeaError(err error) bool {
+	_, ok := err.(NoIdeaError)
+	return ok
+}
+
+// Returns the Idea object stored by the id. If the idea's file
+// doesn't contain an idea this method will return a NoIdeaError.
 func (d DirectoryStore) IdeaById(id uint) (idea Idea, err error) {
 	f, err := os.OpenFile(filepath.Join(d.root, fmt.Sprint(id)), os.O_RDONLY, 0600)
 	if err != nil {
@@ -373,13 +630,15 @@ func (d DirectoryStore) IdeaById(id uint) (idea Idea, err error) {
 	defer f.Close()
 
 	scanner := NewIdeaScanner(f)
-	scanner.Scan()
-
-	if scanner.Err() != nil {
-		return Idea{}, err
+	if !scanner.Scan() {
+		if scanner.Err() != nil {
+			return Idea{}, scanner.Err()
+		}
+		return Idea{}, NoIdeaError{id}
 	}
 
 	idea = *scanner.Idea()
+	d.loaded[id] = idea
 
 	return idea, nil
 }
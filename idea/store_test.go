@@ -3,6 +3,7 @@ package idea
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -21,7 +22,9 @@ type IdeaIO struct {
 }
 
 func SaveIn(d *DirectoryStore, iio *IdeaIO) error {
-	iio.changes, iio.err = d.SaveIdea(iio.idea)
+	ctx := context.Background()
+
+	iio.changes, iio.err = d.SaveIdea(ctx, iio.idea)
 	return iio.err
 }
 
@@ -31,11 +34,15 @@ func SaveNewIn(d *DirectoryStore, iio *IdeaIO) error {
 }
 
 func UpdateIn(d *DirectoryStore, iio *IdeaIO) error {
-	iio.changes, iio.err = d.UpdateIdea(*iio.idea)
+	ctx := context.Background()
+
+	iio.changes, iio.err = d.UpdateIdea(ctx, *iio.idea)
 	return iio.err
 }
 
 func DescribeIdeaStore(c gospec.Context) {
+	ctx := context.Background()
+
 	c.Specify("a directory store", func() {
 		makeEmptyDirectory := func(prefix string) (directory string, cleanUp func()) {
 			directory, err := ioutil.TempDir("", prefix+"_")
@@ -112,9 +119,9 @@ func DescribeIdeaStore(c gospec.Context) {
 				c.Expect(commitable.CommitMsg(), Equals, "idea directory store initialized")
 
 				// Initialize and empty repo
-				c.Assume(git.Init(d), IsNil)
+				c.Assume(git.Init(ctx, d), IsNil)
 				// Commit the directory store initialization
-				c.Expect(git.Commit(commitable), IsNil)
+				c.Expect(git.Commit(ctx, commitable), IsNil)
 
 				o, err := git.Command(d, "show", "--no-color", "--pretty=format:%s").Output()
 				c.Assume(err, IsNil)
@@ -502,7 +509,7 @@ The file should be truncated to reflect the shorter body.
 
 			ideas := append(activeIdeas, inactiveIdeas...)
 			for _, idea := range ideas {
-				_, err := ds.SaveIdea(idea)
+				_, err := ds.SaveIdea(ctx, idea)
 				c.Assume(err, IsNil)
 			}
 
@@ -514,6 +521,57 @@ The file should be truncated to reflect the shorter body.
 			}
 		})
 
+		c.Specify("can reorder the active ideas", func() {
+			ds, _, cleanUp := makeDirectoryStore("directory_store_reorder_active")
+			defer cleanUp()
+
+			for _, status := range []string{IS_Active, IS_Active, IS_Inactive, IS_Active} {
+				_, err := ds.SaveIdea(ctx, &Idea{
+					Status: status,
+					Name:   "an idea",
+					Body:   "an idea body\n",
+				})
+				c.Assume(err, IsNil)
+			}
+			c.Assume(fmt.Sprint(activeIdeasIn(ds)), Equals, "[1 2 4]")
+
+			c.Specify("in the order of a list of ids", func() {
+				changes, err := ds.ReorderActive([]uint{4, 3, 1})
+				c.Assume(err, IsNil)
+
+				// Inactive ideas are ignored and unlisted active ideas are kept after
+				c.Expect(fmt.Sprint(activeIdeasIn(ds)), Equals, "[4 1 2]")
+				c.Expect(changes.Changes(), Contains, git.ChangedFile("active"))
+				c.Expect(changes.CommitMsg(), Equals, "idea - reordered active")
+
+				c.Specify("unless the order isn't changed", func() {
+					_, err := ds.ReorderActive([]uint{4, 1, 2})
+					c.Expect(err, Equals, ErrActiveIndexNotModified)
+				})
+			})
+
+			c.Specify("by moving an idea before another idea", func() {
+				changes, err := ds.MoveActive(4, 2)
+				c.Assume(err, IsNil)
+
+				c.Expect(fmt.Sprint(activeIdeasIn(ds)), Equals, "[1 4 2]")
+				c.Expect(changes.CommitMsg(), Equals, "idea - moved - 4 before 2")
+
+				c.Specify("unless the idea is already before it", func() {
+					_, err := ds.MoveActive(4, 2)
+					c.Expect(err, Equals, ErrActiveIndexNotModified)
+				})
+
+				c.Specify("unless either idea isn't active", func() {
+					_, err := ds.MoveActive(3, 1)
+					c.Expect(err, Equals, IdeaNotActiveError{3})
+
+					_, err = ds.MoveActive(1, 3)
+					c.Expect(err, Equals, IdeaNotActiveError{3})
+				})
+			})
+		})
+
 		c.Specify("can retrieve an idea by it's id", func() {
 			ds, _, cleanUp := makeDirectoryStore("directory_store_active_ideas")
 			defer cleanUp()
@@ -530,5 +588,349 @@ The file should be truncated to reflect the shorter body.
 				c.Expect(idea, Equals, *iio.idea)
 			}
 		})
+
+		c.Specify("will store an idea without modifying it", func() {
+			ds, _, cleanUp := makeDirectoryStore("directory_store_lossless")
+			defer cleanUp()
+
+			idea := Idea{
+				Status: IS_Active,
+				Name:   "[draft] an idea",
+				Body:   "\n## [active] not a header\nno trailing newline",
+			}
+
+			_, err := ds.SaveIdea(ctx, &idea)
+			c.Assume(err, IsNil)
+
+			actual, err := ds.IdeaById(idea.Id)
+			c.Assume(err, IsNil)
+			c.Expect(actual, Equals, idea)
+
+			_, err = ds.UpdateIdea(ctx, actual)
+			c.Expect(err, Equals, ErrIdeaNotModified)
+		})
+
+		c.Specify("will merge the changes made to an idea's file since it was loaded", func() {
+			ds, directory, cleanUp := makeDirectoryStore("directory_store_merge")
+			defer cleanUp()
+
+			original := Idea{
+				Status: IS_Active,
+				Name:   "an idea",
+				Body:   "line 1\nline 2\nline 3\n",
+			}
+			_, err := ds.SaveIdea(ctx, &original)
+			c.Assume(err, IsNil)
+
+			// Load the idea into another store
+			other, err := NewDirectoryStore(directory)
+			c.Assume(err, IsNil)
+
+			loaded, err := other.IdeaById(original.Id)
+			c.Assume(err, IsNil)
+
+			// Modify the idea's file after it was loaded
+			modified := original
+			modified.Body = "line 1 modified\nline 2\nline 3\n"
+			_, err = ds.UpdateIdea(ctx, modified)
+			c.Assume(err, IsNil)
+
+			c.Specify("if they don't conflict", func() {
+				loaded.Body = "line 1\nline 2\nline 3 edited\n"
+				loaded.Status = IS_Inactive
+
+				_, err := other.UpdateIdea(ctx, loaded)
+				c.Assume(err, IsNil)
+
+				actual, err := ds.IdeaById(original.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual.Status, Equals, IS_Inactive)
+				c.Expect(actual.Body, Equals, "line 1 modified\nline 2\nline 3 edited\n")
+			})
+
+			c.Specify("unless the loaded idea wasn't modified", func() {
+				_, err := other.UpdateIdea(ctx, loaded)
+				c.Expect(err, Equals, ErrIdeaNotModified)
+
+				actual, err := ds.IdeaById(original.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, modified)
+			})
+
+			c.Specify("and will fail if they conflict", func() {
+				loaded.Body = "line 1 edited\nline 2\nline 3\n"
+
+				merged, err := other.MergeIdea(ctx, loaded)
+				c.Expect(IsConflictError(err), IsTrue)
+				c.Expect(merged, Equals, Idea{})
+
+				conflict := err.(ConflictError)
+				c.Expect(conflict.Id, Equals, original.Id)
+				c.Expect(conflict.Merged.Body, Equals, `<<<<<<< entry
+line 1 edited
+=======
+line 1 modified
+>>>>>>> idea/1
+line 2
+line 3
+`)
+
+				_, err = other.UpdateIdea(ctx, loaded)
+				c.Expect(IsConflictError(err), IsTrue)
+
+				// The file isn't modified
+				actual, err := ds.IdeaById(original.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, modified)
+			})
+
+			c.Specify("and will merge an idea once", func() {
+				loaded.Name = "a renamed idea"
+
+				merged, err := other.MergeIdea(ctx, loaded)
+				c.Assume(err, IsNil)
+				c.Expect(merged, Equals, Idea{IS_Active, original.Id, "a renamed idea", modified.Body})
+
+				_, err = other.UpdateIdea(ctx, merged)
+				c.Assume(err, IsNil)
+
+				actual, err := ds.IdeaById(original.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, merged)
+			})
+		})
+
+		c.Specify("can merge an idea into another idea", func() {
+			ds, directory, cleanUp := makeDirectoryStore("directory_store_merge_into")
+			defer cleanUp()
+
+			src := Idea{IS_Active, 0, "a duplicate", "Due: 2015-01-02\nsome notes\n- [ ] a task"}
+			dst := Idea{IS_Active, 0, "an idea", "Due: 2015-01-05\n- [ ] a task\n"}
+			for _, idea := range []*Idea{&src, &dst} {
+				_, err := ds.SaveIdea(ctx, idea)
+				c.Assume(err, IsNil)
+			}
+
+			changes, err := ds.MergeInto(src.Id, dst.Id)
+			c.Assume(err, IsNil)
+			c.Expect(changes.CommitMsg(), Equals, "idea - merged - 1 into 2")
+			c.Expect(len(changes.Changes()), Equals, 3)
+
+			c.Specify("by appending the notes and tasks dst doesn't have", func() {
+				actual, err := ds.IdeaById(dst.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual.Body, Equals, "Due: 2015-01-05\n- [ ] a task\n\nMerged from [1] a duplicate\nsome notes\n")
+			})
+
+			c.Specify("and marking the source as merged", func() {
+				actual, err := ds.IdeaById(src.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual.Status, Equals, IS_Merged)
+				c.Expect(actual.Body, Equals, src.Body+"\nMerged-Into: 2\n")
+
+				active, err := ioutil.ReadFile(filepath.Join(directory, "active"))
+				c.Assume(err, IsNil)
+				c.Expect(string(active), Equals, "2\n")
+			})
+
+			c.Specify("unless the ideas are the same", func() {
+				_, err := ds.MergeInto(dst.Id, dst.Id)
+				c.Expect(err, Equals, ErrMergeIntoSelf)
+			})
+		})
+
+		c.Specify("can split an idea into a new idea", func() {
+			ds, _, cleanUp := makeDirectoryStore("directory_store_split")
+			defer cleanUp()
+
+			original := Idea{IS_Active, 0, "an idea", "part 1\npart 2\n"}
+			_, err := ds.SaveIdea(ctx, &original)
+			c.Assume(err, IsNil)
+
+			original.Body = "part 1\n"
+			split := Idea{IS_Inactive, 0, "part 2", "part 2\n"}
+
+			changes, err := ds.SplitIdea(ctx, original, &split)
+			c.Assume(err, IsNil)
+			c.Expect(split.Id, Equals, uint(2))
+			c.Expect(changes.CommitMsg(), Equals, "idea - split - 2 from 1")
+
+			for _, idea := range []Idea{original, split} {
+				actual, err := ds.IdeaById(idea.Id)
+				c.Assume(err, IsNil)
+				c.Expect(actual, Equals, idea)
+			}
+
+			c.Specify("unless the split idea is invalid", func() {
+				_, err := ds.SplitIdea(ctx, original, &Idea{"unknown", 0, "part 3", ""})
+				c.Expect(err, Equals, UnknownStatusError{"unknown"})
+			})
+		})
+
+		c.Specify("can find the ideas with a similar name", func() {
+			ds, _, cleanUp := makeDirectoryStore("directory_store_similar")
+			defer cleanUp()
+
+			for _, idea := range []Idea{
+				{IS_Active, 0, "Write the quarterly report", ""},
+				{IS_Inactive, 0, "Write a quarterly report", ""},
+				{IS_Active, 0, "Plan a vacation", ""},
+			} {
+				_, err := ds.SaveIdea(ctx, &idea)
+				c.Assume(err, IsNil)
+			}
+
+			similar, err := ds.SimilarIdeas("write the Quarterly Report.", DefaultSimilarity)
+			c.Assume(err, IsNil)
+			c.Assume(len(similar), Equals, 2)
+			c.Expect(similar[0].Id, Equals, uint(1))
+			c.Expect(similar[1].Id, Equals, uint(2))
+			c.Expect(similar[1].Status, Equals, IS_Inactive)
+
+			c.Specify("except the ideas that have been merged", func() {
+				_, err := ds.MergeInto(2, 1)
+				c.Assume(err, IsNil)
+
+				similar, err := ds.SimilarIdeas("write the Quarterly Report.", DefaultSimilarity)
+				c.Assume(err, IsNil)
+				c.Assume(len(similar), Equals, 1)
+				c.Expect(similar[0].Id, Equals, uint(1))
+			})
+		})
+
+		c.Specify("will fail to retrieve an idea from a file", func() {
+			ds, directory, cleanUp := makeDirectoryStore("directory_store_by_id")
+			defer cleanUp()
+
+			c.Specify("that is empty", func() {
+				c.Assume(ioutil.WriteFile(filepath.Join(directory, "1"), nil, 0600), IsNil)
+
+				_, err := ds.IdeaById(1)
+				c.Expect(err, Equals, NoIdeaError{1})
+			})
+
+			c.Specify("that doesn't contain a valid idea", func() {
+				c.Assume(ioutil.WriteFile(filepath.Join(directory, "1"), []byte("## [active [1] an idea\n"), 0600), IsNil)
+
+				_, err := ds.IdeaById(1)
+				c.Expect(err, Not(IsNil))
+				c.Expect(IsNoIdeaError(err), IsFalse)
+			})
+		})
+
+		c.Specify("can find the idea with the same name", func() {
+			ds, _, cleanUp := makeDirectoryStore("directory_store_named")
+			defer cleanUp()
+
+			for _, idea := range []Idea{
+				{IS_Active, 0, "Write the report", ""},
+				{IS_Active, 0, "???", ""},
+			} {
+				_, err := ds.SaveIdea(ctx, &idea)
+				c.Assume(err, IsNil)
+			}
+
+			idea, found, err := ds.IdeaNamed("???")
+			c.Assume(err, IsNil)
+			c.Expect(found, IsTrue)
+			c.Expect(idea.Id, Equals, uint(2))
+
+			_, found, err = ds.IdeaNamed("write the report")
+			c.Assume(err, IsNil)
+			c.Expect(found, IsFalse)
+		})
+
+		c.Specify("can be configured with custom statuses", func() {
+			ds, _, cleanUp := makeDirectoryStore("directory_store_statuses")
+			defer cleanUp()
+
+			c.Assume(ds.SetStatuses(Statuses{
+				{Name: "active", CarryOver: true},
+				{Name: "blocked", CarryOver: true, Transitions: []string{"active"}},
+				{Name: "someday"},
+			}), IsNil)
+
+			blocked := &Idea{
+				Status: "blocked",
+				Name:   "blocked idea",
+				Body:   "blocked idea body\n",
+			}
+			_, err := ds.SaveIdea(ctx, blocked)
+			c.Assume(err, IsNil)
+
+			c.Specify("that are carried over into new entries", func() {
+				c.Expect(activeIdeasIn(ds), ContainsExactly, []uint{blocked.Id})
+
+				blocked.Status = "active"
+				_, err := ds.UpdateIdea(ctx, *blocked)
+				c.Assume(err, IsNil)
+				c.Expect(activeIdeasIn(ds), ContainsExactly, []uint{blocked.Id})
+			})
+
+			c.Specify("that are not carried over into new entries", func() {
+				someday := &Idea{
+					Status: "someday",
+					Name:   "someday idea",
+					Body:   "someday idea body\n",
+				}
+				_, err := ds.SaveIdea(ctx, someday)
+				c.Assume(err, IsNil)
+				c.Expect(activeIdeasIn(ds), Not(Contains), someday.Id)
+			})
+
+			c.Specify("and will reject an unknown status", func() {
+				_, err := ds.SaveIdea(ctx, &Idea{
+					Status: IS_Completed,
+					Name:   "completed idea",
+					Body:   "completed idea body\n",
+				})
+				c.Expect(err, Equals, UnknownStatusError{IS_Completed})
+
+				blocked.Status = IS_Completed
+				_, err = ds.UpdateIdea(ctx, *blocked)
+				c.Expect(err, Equals, UnknownStatusError{IS_Completed})
+			})
+
+			c.Specify("and will reject a transition that isn't allowed", func() {
+				blocked.Status = "someday"
+				_, err := ds.UpdateIdea(ctx, *blocked)
+				c.Expect(err, Equals, InvalidTransitionError{blocked.Id, "blocked", "someday"})
+
+				// The idea will not be modified
+				idea, err := ds.IdeaById(blocked.Id)
+				c.Assume(err, IsNil)
+				c.Expect(idea.Status, Equals, "blocked")
+			})
+
+			c.Specify("that determine the status an idea is reactivated with", func() {
+				statuses := Statuses{
+					{Name: "someday", Transitions: []string{"blocked"}},
+					{Name: "active", CarryOver: true},
+					{Name: "blocked", CarryOver: true},
+					{Name: "dropped", Transitions: []string{"someday"}},
+				}
+
+				status, err := statuses.Reactivated("blocked")
+				c.Assume(err, IsNil)
+				c.Expect(status, Equals, "blocked")
+
+				status, err = statuses.Reactivated("someday")
+				c.Assume(err, IsNil)
+				c.Expect(status, Equals, "blocked")
+
+				_, err = statuses.Reactivated("dropped")
+				c.Expect(err, Equals, InvalidTransitionError{From: "dropped", To: "blocked"})
+
+				_, err = Statuses{{Name: "someday"}}.Reactivated("someday")
+				c.Expect(err, Equals, ErrNoCarriedOverStatus)
+			})
+
+			c.Specify("unless they are invalid", func() {
+				c.Expect(ds.SetStatuses(Statuses{}), Not(IsNil))
+				c.Expect(ds.SetStatuses(Statuses{{Name: "two words"}}), Not(IsNil))
+				c.Expect(ds.SetStatuses(Statuses{{Name: "a"}, {Name: "a"}}), Not(IsNil))
+				c.Expect(ds.SetStatuses(Statuses{{Name: "a", Transitions: []string{"b"}}}), Not(IsNil))
+			})
+		})
 	})
 }
@@ -0,0 +1,191 @@
+package idea
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// The kind of a line in the idea format
+type TokenKind int
+
+const (
+	// A line of markdown text. Includes the lines of a fenced code block.
+	TextToken TokenKind = iota
+
+	// The `## [status] [id] name` line that starts an idea
+	HeaderToken
+
+	// The timestamp an entry is closed with.
+	// Only the last non-blank line of the input can be a timestamp.
+	TimestampToken
+)
+
+// A line of input and its kind
+type Token struct {
+	Kind TokenKind
+
+	// The line number of the token, starting at 1
+	Line int
+
+	// The text of the line including the trailing newline
+	Text string
+
+	// True if the line is part of a fenced code block
+	Fenced bool
+}
+
+// Returned when the idea format can't be parsed
+type ParseError struct {
+	Line, Column int
+	Err          error
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func IsParseError(err error) bool {
+	_, ok := err.(ParseError)
+	return ok
+}
+
+// Splits the idea format into lines and classifies each line.
+// Idea headers are only recognized at the start of a line
+// that isn't within a fenced code block.
+type Tokenizer struct {
+	scanner *bufio.Scanner
+
+	// Lines that have been read ahead of the current token
+	pending []string
+
+	// The marker that opened the current fenced code block
+	fence string
+
+	token Token
+}
+
+// Create a Tokenizer using an io.Reader
+func NewTokenizer(r io.Reader) *Tokenizer {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(ScanLines)
+	return &Tokenizer{scanner: scanner}
+}
+
+func (t *Tokenizer) nextLine() (string, bool) {
+	if len(t.pending) > 0 {
+		line := t.pending[0]
+		t.pending = t.pending[1:]
+		return line, true
+	}
+
+	if t.scanner.Scan() {
+		return t.scanner.Text(), true
+	}
+
+	return "", false
+}
+
+// Returns true if there are only blank lines left in the input
+func (t *Tokenizer) atEnd() bool {
+	for _, line := range t.pending {
+		if len(strings.TrimSpace(line)) != 0 {
+			return false
+		}
+	}
+
+	for t.scanner.Scan() {
+		line := t.scanner.Text()
+		t.pending = append(t.pending, line)
+
+		if len(strings.TrimSpace(line)) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the fence marker if the line opens or closes a fenced code block
+func fenceOf(line string) string {
+	line = strings.TrimRight(line, "\r\n")
+
+	// A fence can be indented by up to 3 spaces
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return ""
+	}
+
+	n := 0
+	for n < len(trimmed) && trimmed[n] == c {
+		n++
+	}
+
+	if n < 3 {
+		return ""
+	}
+
+	return trimmed[:n]
+}
+
+// Returns true if the line is a timestamp an entry can be closed with
+func isTimestamp(line string) bool {
+	_, err := time.Parse(time.UnixDate, strings.TrimRight(line, "\r\n"))
+	return err == nil
+}
+
+// Returns true if the line starts an idea
+func isHeader(line string) bool {
+	return strings.HasPrefix(line, "## [")
+}
+
+// Advances the tokenizer to the next line
+func (t *Tokenizer) Scan() bool {
+	line, ok := t.nextLine()
+	if !ok {
+		return false
+	}
+
+	t.token = Token{TextToken, t.token.Line + 1, line, false}
+
+	switch {
+	case isTimestamp(line) && t.atEnd():
+		t.token.Kind = TimestampToken
+
+	case t.fence != "":
+		t.token.Fenced = true
+
+		// A fence is closed by a fence w/o an info string
+		// that is at least as long as the opening fence
+		marker := fenceOf(line)
+		if strings.HasPrefix(marker, t.fence) && strings.TrimSpace(line) == marker {
+			t.fence = ""
+		}
+
+	case fenceOf(line) != "":
+		t.token.Fenced = true
+		t.fence = fenceOf(line)
+
+	case isHeader(line):
+		t.token.Kind = HeaderToken
+	}
+
+	return true
+}
+
+// Returns the current token
+func (t *Tokenizer) Token() Token {
+	return t.token
+}
+
+// Returns the error encountered reading the input
+func (t *Tokenizer) Err() error {
+	return t.scanner.Err()
+}
@@ -0,0 +1,55 @@
+package init
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ghthor/journal/git"
+)
+
+// The version of the storage format a journal is initialized with and
+// the latest version `journal fix` upgrades a journal to. Increment it
+// when adding a migration to the fix package.
+const FormatVersion = 1
+
+// The file in a journal's directory that records
+// the version of the journal's storage format
+const FormatFilename = "format"
+
+// Returns the version of the storage format recorded in the journal.
+// A journal without a version recorded returns 0 and false.
+func FormatVersionOf(directory string) (version int, recorded bool, err error) {
+	data, err := ioutil.ReadFile(filepath.Join(directory, FormatFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	version, err = strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || version < 0 {
+		return 0, false, fmt.Errorf("invalid storage format version in %s: %q", filepath.Join(directory, FormatFilename), strings.TrimSpace(string(data)))
+	}
+
+	return version, true, nil
+}
+
+// Records the version of the journal's storage format and
+// returns a commitable with the change.
+func WriteFormatVersion(directory string, version int) (git.Commitable, error) {
+	err := ioutil.WriteFile(filepath.Join(directory, FormatFilename), []byte(fmt.Sprintln(version)), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := git.NewChangesIn(directory)
+	changes.Add(git.ChangedFile(FormatFilename))
+	changes.Msg = fmt.Sprintf("format version %d", version)
+
+	return changes, nil
+}
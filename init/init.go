@@ -1,6 +1,7 @@
 package init
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -12,14 +13,8 @@ import (
 )
 
 func isAGitRepository(directory string) bool {
-	c := git.Command(directory, "status", "-s")
-
-	_, err := c.CombinedOutput()
-	if err != nil {
-		return false
-	}
-
-	return true
+	_, err := git.FindGitDir(directory)
+	return err == nil
 }
 
 func CanBeInitialized(directory string) (bool, error) {
@@ -90,7 +85,7 @@ func HasBeenInitialized(directory string) bool {
 	return isGit && containsEntryStore && containsIdeaStore
 }
 
-func Journal(directory string) (git.Commitable, error) {
+func Journal(ctx context.Context, directory string) (git.Commitable, error) {
 	_, err := CanBeInitialized(directory)
 	if err != nil {
 		return nil, err
@@ -112,7 +107,7 @@ func Journal(directory string) (git.Commitable, error) {
 
 	// Check if we need to `git init` the directory
 	if !isAGitRepository(directory) {
-		err := git.Init(directory)
+		_, err := git.InitRepository(ctx, directory, git.AutoBackend)
 
 		if err != nil {
 			return nil, err
@@ -136,5 +131,26 @@ func Journal(directory string) (git.Commitable, error) {
 		return nil, err
 	}
 
-	return commitable, nil
+	// Resolve merges of the idea store with the merge drivers
+	attributes, err := WriteGitAttributes(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	err = RegisterMergeDrivers(ctx, directory)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := WriteFormatVersion(directory, FormatVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	commitables := []git.Commitable{commitable, format}
+	if attributes != nil {
+		commitables = append(commitables, attributes)
+	}
+
+	return git.Combine(commitable.CommitMsg(), commitables...), nil
 }
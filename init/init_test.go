@@ -1,6 +1,7 @@
 package init_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/ghthor/gospec"
@@ -47,6 +48,8 @@ func HasBeenInitialized(actual, ignoredParam interface{}) (hasBeenInitialized bo
 }
 
 func DescribeInit(c gospec.Context) {
+	ctx := context.Background()
+
 	c.Specify("a journal", func() {
 		tmpJournal := func() (directory string, commitable git.Commitable, cleanUp func()) {
 			directory, err := ioutil.TempDir("", "journal_init_")
@@ -54,7 +57,7 @@ func DescribeInit(c gospec.Context) {
 
 			c.Assume(directory, Not(HasBeenInitialized))
 
-			commitable, err = jinit.Journal(directory)
+			commitable, err = jinit.Journal(ctx, directory)
 			c.Assume(err, IsNil)
 
 			cleanUp = func() {
@@ -100,7 +103,7 @@ func DescribeInit(c gospec.Context) {
 						c.Specify("that can have ideas", func() {
 							c.Expect(jd, HasBeenInitialized)
 
-							ids.SaveIdea(&idea.Idea{
+							ids.SaveIdea(ctx, &idea.Idea{
 								Name: "An Idea",
 								Body: "A Body\n",
 							})
@@ -111,11 +114,36 @@ func DescribeInit(c gospec.Context) {
 				})
 			})
 
+			c.Specify("merges the idea store with the merge drivers", func() {
+				attributes, err := ioutil.ReadFile(filepath.Join(jd, ".gitattributes"))
+				c.Assume(err, IsNil)
+				c.Expect(string(attributes), Equals, "idea/active merge=journal-active\nidea/nextid merge=journal-nextid\n")
+
+				for _, d := range jinit.MergeDrivers {
+					driver, err := git.Command(jd, "config", "merge."+d.Name+".driver").Output()
+					c.Assume(err, IsNil)
+					c.Expect(string(driver), Equals, d.Command+"\n")
+				}
+
+				c.Specify("without assigning the drivers again", func() {
+					commitable, err := jinit.WriteGitAttributes(jd)
+					c.Assume(err, IsNil)
+					c.Expect(commitable, IsNil)
+				})
+			})
+
+			c.Specify("records the version of the storage format", func() {
+				version, recorded, err := jinit.FormatVersionOf(jd)
+				c.Assume(err, IsNil)
+				c.Expect(recorded, IsTrue)
+				c.Expect(version, Equals, jinit.FormatVersion)
+			})
+
 			c.Specify("has commitable changes", func() {
-				c.Assume(git.IsClean(jd), Not(IsNil))
+				c.Assume(git.IsClean(ctx, jd), Not(IsNil))
 
-				c.Expect(git.Commit(commitable), IsNil)
-				c.Expect(git.IsClean(jd), IsNil)
+				c.Expect(git.Commit(ctx, commitable), IsNil)
+				c.Expect(git.IsClean(ctx, jd), IsNil)
 			})
 		})
 
@@ -140,11 +168,11 @@ func DescribeInit(c gospec.Context) {
 					jd := filepath.Join(directory, "journal")
 
 					c.Assume(os.MkdirAll(jd, 0755), IsNil)
-					c.Assume(git.Init(directory), IsNil)
+					c.Assume(git.Init(ctx, directory), IsNil)
 
 					c.Expect(jd, CanBeInitialized)
 
-					_, err := jinit.Journal(jd)
+					_, err := jinit.Journal(ctx, jd)
 					c.Assume(err, IsNil)
 					c.Expect(jd, HasBeenInitialized)
 					c.Expect(jd, Not(gittest.IsAGitRepository))
@@ -153,7 +181,7 @@ func DescribeInit(c gospec.Context) {
 				c.Specify("NOT inside a git repository", func() {
 					c.Expect(directory, CanBeInitialized)
 
-					_, err := jinit.Journal(directory)
+					_, err := jinit.Journal(ctx, directory)
 					c.Assume(err, IsNil)
 					c.Expect(directory, HasBeenInitialized)
 					c.Expect(directory, gittest.IsAGitRepository)
@@ -164,11 +192,11 @@ func DescribeInit(c gospec.Context) {
 				directory, cleanUp := tmpDir()
 				defer cleanUp()
 
-				c.Assume(git.Init(directory), IsNil)
+				c.Assume(git.Init(ctx, directory), IsNil)
 
 				c.Expect(directory, CanBeInitialized)
 
-				_, err := jinit.Journal(directory)
+				_, err := jinit.Journal(ctx, directory)
 				c.Assume(err, IsNil)
 				c.Expect(directory, HasBeenInitialized)
 			})
@@ -187,12 +215,12 @@ func DescribeInit(c gospec.Context) {
 					base, cleanUp := tmpDir()
 					defer cleanUp()
 
-					c.Assume(git.Init(base), IsNil)
+					c.Assume(git.Init(ctx, base), IsNil)
 
 					jd := filepath.Join(base, "doesntexistyet")
 					c.Expect(jd, CanBeInitialized)
 
-					_, err := jinit.Journal(jd)
+					_, err := jinit.Journal(ctx, jd)
 					c.Assume(err, IsNil)
 					c.Expect(jd, HasBeenInitialized)
 					c.Expect(jd, Not(gittest.IsAGitRepository))
@@ -210,7 +238,7 @@ func DescribeInit(c gospec.Context) {
 
 				c.Expect(f.Name(), Not(CanBeInitialized))
 
-				_, err = jinit.Journal(f.Name())
+				_, err = jinit.Journal(ctx, f.Name())
 				c.Assume(err, Not(IsNil))
 				c.Expect(err.Error(), Equals, fmt.Sprintf("\"%s\" isn't a directory", f.Name()))
 			})
@@ -225,7 +253,7 @@ func DescribeInit(c gospec.Context) {
 
 						c.Expect(directory, Not(CanBeInitialized))
 
-						_, err := jinit.Journal(directory)
+						_, err := jinit.Journal(ctx, directory)
 						c.Assume(err, Not(IsNil))
 						c.Expect(err.Error(), Equals, fmt.Sprintf("\"%s\" isn't an empty directory", directory))
 					})
@@ -239,7 +267,7 @@ func DescribeInit(c gospec.Context) {
 
 						c.Expect(directory, Not(CanBeInitialized))
 
-						_, err = jinit.Journal(directory)
+						_, err = jinit.Journal(ctx, directory)
 						c.Assume(err, Not(IsNil))
 						c.Expect(err.Error(), Equals, fmt.Sprintf("\"%s\" isn't an empty directory", directory))
 					})
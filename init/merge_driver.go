@@ -0,0 +1,117 @@
+package init
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghthor/journal/git"
+)
+
+// A git merge driver that resolves a file in the idea store
+type MergeDriver struct {
+	// The name of the driver in .gitattributes and the repository config
+	Name string
+
+	// The path of the file it merges, relative to the journal's directory
+	Path string
+
+	// The command git executes to merge the file
+	Command string
+}
+
+// The merge drivers that resolve the files in the idea
+// store that are changed by almost every entry.
+var MergeDrivers = []MergeDriver{{
+	Name:    "journal-active",
+	Path:    "idea/active",
+	Command: "journal merge-driver active %O %A %B",
+}, {
+	Name:    "journal-nextid",
+	Path:    "idea/nextid",
+	Command: "journal merge-driver nextid %O %A %B",
+}}
+
+// The line in .gitattributes that assigns the driver to its path
+func (d MergeDriver) attribute() string {
+	return d.Path + " merge=" + d.Name
+}
+
+// Defines the merge drivers in the config of the repository
+// containing directory. The config isn't shared by clones of a
+// repository so every clone must register the drivers. Does
+// nothing if git isn't installed because go-git can't merge.
+func RegisterMergeDrivers(ctx context.Context, directory string) error {
+	for _, d := range MergeDrivers {
+		err := git.SetConfig(ctx, directory, "merge."+d.Name+".name", "journal "+filepath.Base(d.Path))
+		if err != nil {
+			if err == git.ErrGitNotInstalled {
+				return nil
+			}
+			return err
+		}
+
+		err = git.SetConfig(ctx, directory, "merge."+d.Name+".driver", d.Command)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Assigns the merge drivers to the files in the idea store
+// by adding the lines that are missing from .gitattributes.
+// Returns nil if .gitattributes doesn't need to be changed.
+func WriteGitAttributes(directory string) (git.Commitable, error) {
+	filename := filepath.Join(directory, ".gitattributes")
+
+	existing, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	lines := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(existing))
+	for scanner.Scan() {
+		lines[string(bytes.TrimSpace(scanner.Bytes()))] = true
+	}
+
+	missing := bytes.NewBuffer(nil)
+	if len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		missing.WriteByte('\n')
+	}
+
+	isMissing := false
+	for _, d := range MergeDrivers {
+		if !lines[d.attribute()] {
+			isMissing = true
+			fmt.Fprintln(missing, d.attribute())
+		}
+	}
+
+	if !isMissing {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	_, err = missing.WriteTo(f)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := git.NewChangesIn(directory)
+	changes.Msg = "merge drivers assigned"
+	changes.Add(git.ChangedFile(".gitattributes"))
+
+	return changes, nil
+}
@@ -0,0 +1,237 @@
+// Package lock implements an advisory lock file that is
+// used to serialize the mutating operations performed on
+// a journal by multiple processes.
+//
+// The lock file contains the pid of the process holding the lock.
+// If that process no longer exists the lock is considered
+// stale and will be broken by the next process to acquire it.
+package lock
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/ghthor/journal/git"
+)
+
+// The filename used for a journal's lock file
+const Filename = "journal.lock"
+
+// Returned if the lock is held by another process
+type LockedError struct {
+	Path string
+	Pid  int
+}
+
+func (e LockedError) Error() string {
+	return fmt.Sprintf("journal is locked by pid %d", e.Pid)
+}
+
+func IsLockedError(err error) bool {
+	_, ok := err.(LockedError)
+	return ok
+}
+
+// A lock that has been acquired by this process
+type Lock struct {
+	path string
+}
+
+// Locks held by this process are reentrant.
+// The lock file is only removed when every
+// acquisition has been released.
+var held = struct {
+	sync.Mutex
+	count map[string]int
+}{count: make(map[string]int)}
+
+// Acquire the lock stored in the file at path.
+// If the lock is held by another living process
+// a LockedError is returned.
+func Acquire(path string) (*Lock, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	held.Lock()
+	defer held.Unlock()
+
+	if held.count[path] > 0 {
+		held.count[path]++
+		return &Lock{path}, nil
+	}
+
+	err = create(path)
+	if os.IsExist(err) {
+		// Break the lock if the process holding it has exited
+		var pid int
+		pid, err = pidIn(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if isAlive(pid) && pid != os.Getpid() {
+			return nil, LockedError{path, pid}
+		}
+
+		err = breakStale(path)
+		if os.IsExist(err) {
+			pid, _ = pidIn(path)
+			return nil, LockedError{path, pid}
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	held.count[path] = 1
+	return &Lock{path}, nil
+}
+
+// Acquire the lock for the journal in directory.
+// The lock file is stored in the git directory of the
+// repository containing the journal so it will never
+// appear as a change in the repository.
+// If directory isn't within a git repository
+// the lock file is stored in directory.
+func Journal(directory string) (*Lock, error) {
+	return Acquire(filepath.Join(lockDirFor(directory), Filename))
+}
+
+func lockDirFor(directory string) string {
+	gitDir, err := git.FindGitDir(directory)
+	if err != nil {
+		return directory
+	}
+
+	return gitDir
+}
+
+// Release the lock. The lock file will be removed once
+// every acquisition made by this process has been released.
+func (l *Lock) Release() error {
+	held.Lock()
+	defer held.Unlock()
+
+	if held.count[l.path] == 0 {
+		return fmt.Errorf("lock %s is not held", l.path)
+	}
+
+	held.count[l.path]--
+	if held.count[l.path] > 0 {
+		return nil
+	}
+
+	delete(held.count, l.path)
+	return os.Remove(l.path)
+}
+
+// Replaces a stale lock file with a lock held by this process.
+// Processes breaking a lock are serialized by an exclusive flock of
+// the lock file's directory and the pid is checked again while it's
+// held, so a lock acquired by a process that broke it first is never
+// removed. The flock is released by the kernel if this process exits.
+func breakStale(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	err = syscall.Flock(int(dir.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		return err
+	}
+	defer syscall.Flock(int(dir.Fd()), syscall.LOCK_UN)
+
+	pid, err := pidIn(path)
+	if err != nil {
+		return err
+	}
+
+	if isAlive(pid) && pid != os.Getpid() {
+		return LockedError{path, pid}
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return create(path)
+}
+
+// Atomically create the lock file containing the pid
+// of this process. Returns an error satisfying
+// os.IsExist if the lock file already exists.
+func create(path string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = fmt.Fprintln(tmp, os.Getpid())
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	// Link will fail if the lock file already exists
+	err = os.Link(tmp.Name(), path)
+	if os.IsExist(err) {
+		return os.ErrExist
+	}
+
+	return err
+}
+
+func pidIn(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(string(bytes.TrimSpace(data)))
+	if err != nil {
+		// A garbled lock file can't belong to a living process
+		return 0, nil
+	}
+
+	return pid, nil
+}
+
+func isAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	err = p.Signal(syscall.Signal(0))
+	if err == nil {
+		return true
+	}
+
+	// The process exists but is owned by another user
+	return err == syscall.EPERM
+}
@@ -0,0 +1,100 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ghthor/gospec"
+	. "github.com/ghthor/gospec"
+
+	"github.com/ghthor/journal/git"
+)
+
+func DescribeLock(c gospec.Context) {
+	ctx := context.Background()
+
+	d, err := ioutil.TempDir("", "lock_")
+	c.Assume(err, IsNil)
+	defer func() {
+		c.Assume(os.RemoveAll(d), IsNil)
+	}()
+
+	path := filepath.Join(d, Filename)
+
+	c.Specify("a lock", func() {
+		c.Specify("can be acquired", func() {
+			l, err := Acquire(path)
+			c.Assume(err, IsNil)
+
+			c.Specify("by writing the pid to the lock file", func() {
+				data, err := ioutil.ReadFile(path)
+				c.Assume(err, IsNil)
+				c.Expect(string(data), Equals, fmt.Sprintln(os.Getpid()))
+			})
+
+			c.Specify("more than once by the same process", func() {
+				l2, err := Acquire(path)
+				c.Assume(err, IsNil)
+
+				c.Expect(l2.Release(), IsNil)
+				_, err = os.Stat(path)
+				c.Expect(err, IsNil)
+			})
+
+			c.Specify("and released", func() {
+				c.Expect(l.Release(), IsNil)
+				_, err := os.Stat(path)
+				c.Expect(os.IsNotExist(err), IsTrue)
+			})
+		})
+
+		c.Specify("cannot be acquired if held by another process", func() {
+			// The parent process is guaranteed to be alive
+			c.Assume(ioutil.WriteFile(path, []byte(fmt.Sprintln(os.Getppid())), 0600), IsNil)
+
+			_, err := Acquire(path)
+			c.Expect(IsLockedError(err), IsTrue)
+			c.Expect(err.Error(), Equals, fmt.Sprintf("journal is locked by pid %d", os.Getppid()))
+		})
+
+		c.Specify("will be broken if it is stale", func() {
+			// Start and wait for a process to obtain a pid that no longer exists
+			proc := exec.Command("true")
+			c.Assume(proc.Run(), IsNil)
+
+			c.Assume(ioutil.WriteFile(path, []byte(fmt.Sprintln(proc.Process.Pid)), 0600), IsNil)
+
+			l, err := Acquire(path)
+			c.Expect(err, IsNil)
+			c.Expect(l.Release(), IsNil)
+		})
+
+		c.Specify("won't be broken if it was acquired after it was found to be stale", func() {
+			// Another process broke the stale lock and acquired it first
+			c.Assume(ioutil.WriteFile(path, []byte(fmt.Sprintln(os.Getppid())), 0600), IsNil)
+
+			err := breakStale(path)
+			c.Expect(IsLockedError(err), IsTrue)
+
+			data, err := ioutil.ReadFile(path)
+			c.Assume(err, IsNil)
+			c.Expect(string(data), Equals, fmt.Sprintln(os.Getppid()))
+		})
+
+		c.Specify("for a journal is stored in the git directory", func() {
+			c.Assume(git.Init(ctx, d), IsNil)
+
+			l, err := Journal(d)
+			c.Assume(err, IsNil)
+			defer l.Release()
+
+			_, err = os.Stat(filepath.Join(d, ".git", Filename))
+			c.Expect(err, IsNil)
+			c.Expect(git.IsClean(ctx, d), IsNil)
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package lock
+
+import (
+	"github.com/ghthor/gospec"
+	"testing"
+)
+
+func TestUnitSpecs(t *testing.T) {
+	r := gospec.NewRunner()
+
+	r.AddSpec(DescribeLock)
+
+	gospec.MainGoTest(r, t)
+}
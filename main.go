@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"text/template"
 
+	"github.com/ghthor/journal/cmd"
 	"github.com/ghthor/journal/cmd_verbs"
 )
 
@@ -51,6 +53,10 @@ func main() {
 		showUsageAndExit(EC_NO_CMD)
 	}
 
+	// Interrupting cancels the git process being waited on
+	ctx, stop := cmd.InterruptContext(context.Background())
+	defer stop()
+
 	// Retrieve the command bound to the verb
 	cmd := cmd_verbs.MatchVerb(args[0])
 	if cmd == nil {
@@ -73,7 +79,7 @@ func main() {
 	cmd.SetWd(wd)
 
 	// Execute the command
-	err = cmd.Exec(args[1:])
+	err = cmd.Exec(ctx, args[1:])
 	if err != nil {
 		fmt.Printf("error: %s\n", err)
 		os.Exit(EC_CMD_ERROR)